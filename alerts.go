@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// alertMark is a point of a countdown at which an intermediate alert fires:
+// either an absolute remaining time ("5m") or a share of the countdown that
+// has elapsed ("50%")
+type alertMark struct {
+	label     string
+	remaining time.Duration
+	percent   float64
+}
+
+// parseAlerts parses a comma-separated list of alert marks such as
+// "10m,5m,1m" or "50%,90%"
+func parseAlerts(spec string) ([]alertMark, error) {
+	var marks []alertMark
+	for _, field := range strings.Split(spec, ",") {
+		label := strings.TrimSpace(field)
+		if label == "" {
+			continue
+		}
+		mark := alertMark{label: label}
+		if number, ok := strings.CutSuffix(label, "%"); ok {
+			percent, err := strconv.ParseFloat(number, 64)
+			if err != nil || percent <= 0 || percent >= 100 {
+				return nil, fmt.Errorf("invalid alert %q, percentages must be between 0 and 100", label)
+			}
+			mark.percent = percent
+		} else {
+			remaining, err := parseDurationArg(label)
+			if err != nil || remaining <= 0 {
+				return nil, fmt.Errorf("invalid alert %q, expected a duration or a percentage", label)
+			}
+			mark.remaining = remaining
+		}
+		if !slices.ContainsFunc(marks, func(m alertMark) bool { return m.label == label }) {
+			marks = append(marks, mark)
+		}
+	}
+	return marks, nil
+}
+
+// passed reports whether a countdown of duration has reached the mark
+func (m alertMark) passed(elapsed, duration time.Duration) bool {
+	if m.percent > 0 {
+		return float64(elapsed) >= float64(duration)*m.percent/100
+	}
+	return duration-elapsed <= m.remaining
+}
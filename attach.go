@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// attachActions are the key actions a view of a daemon-owned timer
+// supports; the others (reset, lap, the prompt, ...) need the timer itself
+var attachActions = []string{
+	"pause", "add-time", "remove-time", "add-time-small", "remove-time-small",
+	"help", "quit", "interrupt",
+}
+
+// runAttached displays a daemon-owned timer as a pure view. Space forwards
+// pause/resume and the adjustment keys add-time to the daemon; quitting only
+// detaches, the timer keeps running.
+func runAttached(name string, useFullscreen bool) error {
+	client, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer client.close()
+
+	resp, err := client.do(daemonRequest{Cmd: "status", Name: name})
+	if err != nil {
+		return err
+	}
+	session := resp.Timers[0]
+	name = session.Name
+
+	t, err := openTUI(useFullscreen)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	// Poll the daemon quickly; the connection is local and requests are tiny
+	ticker := time.NewTicker(tickIntervalFast)
+	defer ticker.Stop()
+
+	var cachedOutput string
+	var lastFrame frame
+	var layout frameLayout
+	var showHelp bool
+	render := func() {
+		now := time.Now()
+		st := stateFromSession(session, now)
+		f := timerFrame(st, now)
+		f.header = name
+		f.buttons = slices.DeleteFunc(f.buttons, func(b screenButton) bool {
+			return !slices.Contains(attachActions, b.action)
+		})
+		if showHelp {
+			f.overlay = helpOverlay(st, now, useFullscreen, attachActions)
+		}
+		cachedOutput, layout = renderFrame(f, useFullscreen)
+		lastFrame = f
+	}
+
+	// runAction forwards a key action to the daemon and reports whether
+	// the view was detached
+	runAction := func(action string) (bool, error) {
+		switch action {
+		case "pause": // Pause/unpause the daemon timer
+			cmd := "pause"
+			if session.Paused {
+				cmd = "resume"
+			}
+			resp, err := client.do(daemonRequest{Cmd: cmd, Name: name})
+			if err != nil {
+				return false, err
+			}
+			session = resp.Timers[0]
+
+		case "add-time", "remove-time", "add-time-small", "remove-time-small":
+			resp, err := client.do(daemonRequest{Cmd: "add-time", Name: name, Duration: actionAdjustment(action).String()})
+			if err != nil {
+				return false, err
+			}
+			session = resp.Timers[0]
+
+		case "help": // Show or hide the help overlay
+			showHelp = !showHelp
+
+		case "quit", "interrupt": // Detach, leaving the timer running
+			fmt.Print("\r\ndetached\r\n")
+			return true, nil
+		}
+		return false, nil
+	}
+	render()
+	fmt.Print(cachedOutput)
+
+	for {
+		select {
+		case sig := <-t.signals:
+			if sig == syscall.SIGWINCH {
+				render()
+				fmt.Print(cachedOutput)
+				continue
+			}
+			fmt.Print("\r\ndetached\r\n")
+			return nil
+
+		case key := <-t.keys:
+			if done, err := runAction(keymap.action[key]); done || err != nil {
+				return err
+			}
+
+		case m := <-t.mouse:
+			// Clicks and the wheel run the same actions as keys
+			if done, err := runAction(mouseAction(m, lastFrame, layout)); done || err != nil {
+				return err
+			}
+
+		case <-ticker.C:
+			resp, err := client.do(daemonRequest{Cmd: "status", Name: name})
+			if err != nil {
+				return err
+			}
+			session = resp.Timers[0]
+			if session.Finished {
+				fmt.Print("\r\nfinished!\r\n")
+				return nil
+			}
+		}
+
+		render()
+		fmt.Print(cachedOutput)
+	}
+}
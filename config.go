@@ -2,8 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 )
 
@@ -11,6 +13,9 @@ const version = "dev"
 
 // Configuration variables (defaults)
 var (
+	// Path of the config file in use, empty when running on the defaults
+	configFile string
+
 	// Ticker intervals for different duration ranges
 	tickIntervalFast   = 100 * time.Millisecond // For durations < 1 minute
 	tickIntervalMedium = 500 * time.Millisecond // For durations 1-10 minutes
@@ -24,6 +29,9 @@ var (
 	glyphHeight  = 7
 	glyphSpacing = 1
 
+	// Number of recent laps shown under the time in counter mode
+	lapsShown = 3
+
 	// Visual spacing (terminal line height cannot be changed, but we can adjust visual perception)
 
 	// Keyboard input buffer size
@@ -35,21 +43,90 @@ var (
 
 	// Auto-restore from last session
 	restoreEnabled = false
+
+	// History log rotation: size at which history.jsonl is rotated and
+	// number of rotated files kept
+	historyMaxSize int64 = 5 * 1024 * 1024
+	historyKeep          = 5
+
+	// Shell commands run on timer lifecycle events, keyed by event name
+	hooks = map[string]string{}
+
+	// Maximum run time of a single hook command
+	hookTimeout = 5 * time.Second
+
+	// Intermediate alerts fired as a countdown passes them (e.g. "10m,5m,50%")
+	// and whether they also ring the terminal bell
+	defaultAlerts []alertMark
+	alertBell     = false
+
+	// Notification backends, tried in order until one succeeds
+	notifyChain = defaultNotifyChain()
+
+	// Time added or removed with Up/Down or +/-, and with Right/Left
+	adjustStep     = time.Minute
+	adjustFineStep = 10 * time.Second
+
+	// Pomodoro phase lengths and long break interval
+	pomodoroWork           = 25 * time.Minute
+	pomodoroShortBreak     = 5 * time.Minute
+	pomodoroLongBreak      = 15 * time.Minute
+	pomodoroLongBreakEvery = 4
 )
 
 // Config represents the configuration structure for config.json
 type Config struct {
-	TickIntervalFast   time.Duration `json:"tickIntervalFast"`
-	TickIntervalMedium time.Duration `json:"tickIntervalMedium"`
-	TickIntervalSlow   time.Duration `json:"tickIntervalSlow"`
-	WarningThreshold   time.Duration `json:"warningThreshold"`
-	GlyphWidth         int           `json:"glyphWidth"`
-	GlyphHeight        int           `json:"glyphHeight"`
-	GlyphSpacing       int           `json:"glyphSpacing"`
-	KeyBufferSize      int           `json:"keyBufferSize"`
-	DefaultTermWidth   int           `json:"defaultTermWidth"`
-	DefaultTermHeight  int           `json:"defaultTermHeight"`
-	Restore            bool          `json:"restore"`
+	TickIntervalFast   configDuration `json:"tickIntervalFast"`
+	TickIntervalMedium configDuration `json:"tickIntervalMedium"`
+	TickIntervalSlow   configDuration `json:"tickIntervalSlow"`
+	WarningThreshold   configDuration `json:"warningThreshold"`
+	GlyphWidth         int            `json:"glyphWidth"`
+	GlyphHeight        int            `json:"glyphHeight"`
+	GlyphSpacing       int            `json:"glyphSpacing"`
+	KeyBufferSize      int            `json:"keyBufferSize"`
+	DefaultTermWidth   int            `json:"defaultTermWidth"`
+	DefaultTermHeight  int            `json:"defaultTermHeight"`
+	Restore            bool           `json:"restore"`
+
+	Hooks       map[string]string `json:"hooks"`
+	HookTimeout configDuration    `json:"hookTimeout"`
+
+	Notifiers []NotifierConfig `json:"notifiers"`
+	Alerts    string           `json:"alerts"`
+	AlertBell bool             `json:"alertBell"`
+
+	AdjustStep configDuration      `json:"adjustStep"`
+	Keys       map[string][]string `json:"keys"`
+
+	HistoryMaxSize int64 `json:"historyMaxSize"`
+	HistoryKeep    int   `json:"historyKeep"`
+
+	PomodoroWork           configDuration `json:"pomodoroWork"`
+	PomodoroShortBreak     configDuration `json:"pomodoroShortBreak"`
+	PomodoroLongBreak      configDuration `json:"pomodoroLongBreak"`
+	PomodoroLongBreakEvery int            `json:"pomodoroLongBreakEvery"`
+}
+
+// configDuration is a duration in config.json, written in any duration
+// format ("25m", "1:30") or as a number of nanoseconds
+type configDuration time.Duration
+
+func (d *configDuration) UnmarshalJSON(data []byte) error {
+	var arg string
+	if err := json.Unmarshal(data, &arg); err != nil {
+		var ns int64
+		if err := json.Unmarshal(data, &ns); err != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		*d = configDuration(ns)
+		return nil
+	}
+	value, err := parseDurationArg(arg)
+	if err != nil {
+		return err
+	}
+	*d = configDuration(value)
+	return nil
 }
 
 // loadConfig loads configuration from ~/.config/go-timer/config.json
@@ -68,29 +145,23 @@ func loadConfig() {
 
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
-		return // Use defaults
+		fmt.Fprintf(os.Stderr, "Warning: invalid config %s, using the defaults: %v\n", configPath, err)
+		return
 	}
+	configFile = configPath
 
 	// Apply config values with validation (non-zero for durations, positive for ints)
-	if config.TickIntervalFast != 0 {
-		if config.TickIntervalFast >= 10*time.Millisecond && config.TickIntervalFast <= 1*time.Second {
-			tickIntervalFast = config.TickIntervalFast
-		}
+	if d := time.Duration(config.TickIntervalFast); d >= 10*time.Millisecond && d <= 1*time.Second {
+		tickIntervalFast = d
 	}
-	if config.TickIntervalMedium != 0 {
-		if config.TickIntervalMedium >= 10*time.Millisecond && config.TickIntervalMedium <= 1*time.Second {
-			tickIntervalMedium = config.TickIntervalMedium
-		}
+	if d := time.Duration(config.TickIntervalMedium); d >= 10*time.Millisecond && d <= 1*time.Second {
+		tickIntervalMedium = d
 	}
-	if config.TickIntervalSlow != 0 {
-		if config.TickIntervalSlow >= 10*time.Millisecond && config.TickIntervalSlow <= 5*time.Second {
-			tickIntervalSlow = config.TickIntervalSlow
-		}
+	if d := time.Duration(config.TickIntervalSlow); d >= 10*time.Millisecond && d <= 5*time.Second {
+		tickIntervalSlow = d
 	}
-	if config.WarningThreshold != 0 {
-		if config.WarningThreshold >= 1*time.Minute && config.WarningThreshold <= 1*time.Hour {
-			warningThreshold = config.WarningThreshold
-		}
+	if d := time.Duration(config.WarningThreshold); d >= 1*time.Minute && d <= 1*time.Hour {
+		warningThreshold = d
 	}
 	if config.GlyphWidth > 0 && config.GlyphWidth <= 20 {
 		glyphWidth = config.GlyphWidth
@@ -113,4 +184,58 @@ func loadConfig() {
 	if config.Restore {
 		restoreEnabled = config.Restore
 	}
+	for event, command := range config.Hooks {
+		// Unknown events are ignored like other invalid values
+		if slices.Contains(hookEvents, event) && command != "" {
+			hooks[event] = command
+		}
+	}
+	if d := time.Duration(config.HookTimeout); d >= 100*time.Millisecond && d <= 5*time.Minute {
+		hookTimeout = d
+	}
+	var chain []notifierLink
+	for _, c := range config.Notifiers {
+		// Invalid entries are ignored like other invalid values
+		if n, err := newNotifier(c); err == nil {
+			chain = append(chain, notifierLink{notifier: n, next: c.Continue})
+		}
+	}
+	if len(chain) > 0 {
+		notifyChain = chain
+	}
+	if marks, err := parseAlerts(config.Alerts); err == nil && len(marks) > 0 {
+		defaultAlerts = marks
+	}
+	if config.AlertBell {
+		alertBell = config.AlertBell
+	}
+	if d := time.Duration(config.AdjustStep); d >= 1*time.Second && d <= 1*time.Hour {
+		adjustStep = d
+	}
+	if len(config.Keys) > 0 {
+		// Unlike other values, bad bindings are reported (see keymapError)
+		if m, err := buildKeymap(config.Keys); err != nil {
+			keymapError = err
+		} else {
+			keymap = m
+		}
+	}
+	if config.HistoryMaxSize >= 64*1024 && config.HistoryMaxSize <= 1024*1024*1024 {
+		historyMaxSize = config.HistoryMaxSize
+	}
+	if config.HistoryKeep > 0 && config.HistoryKeep <= 100 {
+		historyKeep = config.HistoryKeep
+	}
+	if d := time.Duration(config.PomodoroWork); d >= 1*time.Minute && d <= 4*time.Hour {
+		pomodoroWork = d
+	}
+	if d := time.Duration(config.PomodoroShortBreak); d >= 1*time.Minute && d <= 1*time.Hour {
+		pomodoroShortBreak = d
+	}
+	if d := time.Duration(config.PomodoroLongBreak); d >= 1*time.Minute && d <= 2*time.Hour {
+		pomodoroLongBreak = d
+	}
+	if config.PomodoroLongBreakEvery > 0 && config.PomodoroLongBreakEvery <= 20 {
+		pomodoroLongBreakEvery = config.PomodoroLongBreakEvery
+	}
 }
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"text/tabwriter"
+)
+
+// daemonClient is a connection to the timer daemon's control socket
+type daemonClient struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+}
+
+func dialDaemon() (*daemonClient, error) {
+	socketPath, err := daemonSocketPath()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("daemon not running (start it with 'timer daemon -detach'): %w", err)
+	}
+	return &daemonClient{conn: conn, scanner: bufio.NewScanner(conn)}, nil
+}
+
+func (c *daemonClient) close() {
+	c.conn.Close()
+}
+
+// do sends a request and waits for the response; daemon-side failures are
+// returned as errors
+func (c *daemonClient) do(req daemonRequest) (daemonResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return daemonResponse{}, err
+	}
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		return daemonResponse{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return daemonResponse{}, fmt.Errorf("failed to read response: %w", err)
+		}
+		return daemonResponse{}, fmt.Errorf("daemon closed the connection")
+	}
+	var resp daemonResponse
+	if err := json.Unmarshal(c.scanner.Bytes(), &resp); err != nil {
+		return daemonResponse{}, fmt.Errorf("invalid response: %w", err)
+	}
+	if !resp.OK {
+		return resp, fmt.Errorf("%s", resp.Error)
+	}
+	return resp, nil
+}
+
+func ctlUsage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "Usage: timer ctl [options] <command> [args]\n\n")
+		fmt.Fprintf(os.Stderr, "Commands:\n")
+		fmt.Fprintf(os.Stderr, "  start [<duration>]        start a countdown (or a counter without duration)\n")
+		fmt.Fprintf(os.Stderr, "  pause [<name>]            pause a timer\n")
+		fmt.Fprintf(os.Stderr, "  resume [<name>]           resume a paused timer\n")
+		fmt.Fprintf(os.Stderr, "  add-time [<name>] <dur>   add (or with -1m remove) time\n")
+		fmt.Fprintf(os.Stderr, "  stop [<name>]             stop and remove a timer\n")
+		fmt.Fprintf(os.Stderr, "  status [<name>]           show a timer\n")
+		fmt.Fprintf(os.Stderr, "  list                      list all timers\n\n")
+		fmt.Fprintf(os.Stderr, "The name can be omitted when the daemon owns a single timer.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  timer ctl -name tea start 4m\n")
+		fmt.Fprintf(os.Stderr, "  timer ctl add-time tea 1m\n")
+		fmt.Fprintf(os.Stderr, "  timer ctl pause tea\n")
+		fmt.Fprintf(os.Stderr, "  timer attach tea         # show a daemon timer in this terminal\n")
+	}
+}
+
+// runCtl implements the 'timer ctl' client subcommand
+func runCtl(args []string) error {
+	fs := flag.NewFlagSet("ctl", flag.ExitOnError)
+	name := fs.String("name", "", "name of the timer to start")
+	paused := fs.Bool("paused", false, "start the timer paused")
+	asJSON := fs.Bool("json", false, "print the raw JSON response")
+	fs.Usage = ctlUsage(fs)
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	req := daemonRequest{Cmd: rest[0]}
+	rest = rest[1:]
+	switch req.Cmd {
+	case "start":
+		req.Name = *name
+		req.Paused = *paused
+		if len(rest) > 1 {
+			fs.Usage()
+			os.Exit(1)
+		}
+		if len(rest) == 1 {
+			req.Duration = rest[0]
+		}
+	case "add-time":
+		// add-time [<name>] <duration>
+		switch len(rest) {
+		case 1:
+			req.Duration = rest[0]
+		case 2:
+			req.Name, req.Duration = rest[0], rest[1]
+		default:
+			fs.Usage()
+			os.Exit(1)
+		}
+	case "pause", "resume", "stop", "status":
+		if len(rest) > 1 {
+			fs.Usage()
+			os.Exit(1)
+		}
+		req.Name = *name
+		if len(rest) == 1 {
+			req.Name = rest[0]
+		}
+	case "list":
+		if len(rest) > 0 {
+			fs.Usage()
+			os.Exit(1)
+		}
+	default:
+		return fmt.Errorf("unknown command %q", req.Cmd)
+	}
+
+	client, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer client.close()
+
+	resp, err := client.do(req)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	printTimerTable(resp.Timers)
+	return nil
+}
+
+// printTimerTable prints daemon timers as an aligned table
+func printTimerTable(timers []Session) {
+	if len(timers) == 0 {
+		fmt.Println("no timers")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tMODE\tSTATE\tELAPSED\tREMAINING")
+	for _, s := range timers {
+		state := "running"
+		if s.Finished {
+			state = "finished"
+		} else if s.Paused {
+			state = "paused"
+		}
+		remaining := "-"
+		if s.Mode != "counter" {
+			remaining = formatHMS(parseFormattedDuration(s.Remaining))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, s.Mode, state,
+			formatHMS(parseFormattedDuration(s.Elapsed)), remaining)
+	}
+	w.Flush()
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// daemonRequest is one line of the control protocol sent to the daemon
+type daemonRequest struct {
+	Cmd      string `json:"cmd"`                // start, pause, resume, add-time, stop, list, status
+	Name     string `json:"name,omitempty"`     // target timer (optional if only one exists)
+	Duration string `json:"duration,omitempty"` // countdown length for start, delta for add-time
+	Paused   bool   `json:"paused,omitempty"`   // start in paused state
+}
+
+// daemonResponse is the daemon's one-line reply to a request
+type daemonResponse struct {
+	OK     bool      `json:"ok"`
+	Error  string    `json:"error,omitempty"`
+	Timers []Session `json:"timers,omitempty"`
+}
+
+// daemonSocketPath returns the control socket path in the user runtime dir,
+// creating its directory if needed
+func daemonSocketPath() (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir != "" {
+		dir = filepath.Join(dir, "go-timer")
+	} else {
+		dir = filepath.Join(os.TempDir(), fmt.Sprintf("go-timer-%d", os.Getuid()))
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create runtime dir: %w", err)
+	}
+	return filepath.Join(dir, "daemon.sock"), nil
+}
+
+// daemonTimer is a timer owned by the daemon
+type daemonTimer struct {
+	state      *timerState
+	finished   bool
+	finishedAt time.Time
+}
+
+// session returns the timer snapshot, frozen at the finish time once finished
+func (t *daemonTimer) session(now time.Time) Session {
+	if t.finished {
+		return t.state.session(t.finishedAt, true)
+	}
+	return t.state.session(now, false)
+}
+
+type daemon struct {
+	mu      sync.Mutex
+	timers  map[string]*daemonTimer
+	nextID  int
+	metrics *timerMetrics
+}
+
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	detach := fs.Bool("detach", false, "run the daemon in the background")
+	metricsAddr := fs.String("metrics", "", "serve Prometheus metrics on this address (e.g. 127.0.0.1:9090)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: timer daemon [-detach] [-metrics addr]\n\n")
+		fmt.Fprintf(os.Stderr, "Runs timers independently of any terminal, controlled with 'timer ctl'.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	socketPath, err := daemonSocketPath()
+	if err != nil {
+		return err
+	}
+
+	if *detach {
+		return detachDaemon(socketPath, args)
+	}
+
+	// Refuse to start twice, but clean up a stale socket left by a crash
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		conn.Close()
+		return fmt.Errorf("daemon already running on %s", socketPath)
+	}
+	os.Remove(socketPath)
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	defer os.Remove(socketPath)
+
+	d := &daemon{timers: make(map[string]*daemonTimer), metrics: newTimerMetrics()}
+
+	if *metricsAddr != "" {
+		metricsListener, err := net.Listen("tcp", *metricsAddr)
+		if err != nil {
+			return fmt.Errorf("failed to start metrics endpoint: %w", err)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /metrics", metricsHandler(d.metrics, d.sessions))
+		server := &http.Server{Handler: mux}
+		go server.Serve(metricsListener)
+		defer server.Close()
+	}
+
+	stopCh := make(chan struct{})
+	go d.watch(stopCh)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		close(stopCh)
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				d.interruptAll()
+				return nil
+			}
+			return fmt.Errorf("accept failed: %w", err)
+		}
+		go d.handleConn(conn)
+	}
+}
+
+// detachDaemon starts the daemon in a new session without a terminal and
+// waits until its socket accepts connections
+func detachDaemon(socketPath string, args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+	// Pass the remaining options on to the background daemon
+	daemonArgs := []string{"daemon"}
+	for _, arg := range args {
+		if arg != "-detach" && arg != "--detach" {
+			daemonArgs = append(daemonArgs, arg)
+		}
+	}
+	cmd := exec.Command(exe, daemonArgs...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start daemon: %w", err)
+	}
+	pid := cmd.Process.Pid
+	cmd.Process.Release()
+
+	for i := 0; i < 20; i++ {
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			fmt.Printf("daemon started (pid %d)\n", pid)
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("daemon (pid %d) did not start listening on %s", pid, socketPath)
+}
+
+// handleConn serves line-delimited JSON requests until the client disconnects
+func (d *daemon) handleConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req daemonRequest
+		var resp daemonResponse
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp = daemonResponse{Error: fmt.Sprintf("invalid request: %v", err)}
+		} else {
+			resp = d.handle(req)
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// handle executes a single request against the timer registry
+func (d *daemon) handle(req daemonRequest) daemonResponse {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	switch req.Cmd {
+	case "start":
+		return d.start(req, now)
+
+	case "list":
+		names := make([]string, 0, len(d.timers))
+		for name := range d.timers {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return d.timers[names[i]].state.runStart.Before(d.timers[names[j]].state.runStart)
+		})
+		resp := daemonResponse{OK: true, Timers: []Session{}}
+		for _, name := range names {
+			resp.Timers = append(resp.Timers, d.timers[name].session(now))
+		}
+		return resp
+
+	case "status", "pause", "resume", "add-time", "stop":
+		name, t, err := d.lookup(req.Name)
+		if err != nil {
+			return daemonResponse{Error: err.Error()}
+		}
+		switch req.Cmd {
+		case "pause", "resume":
+			if t.finished {
+				return daemonResponse{Error: fmt.Sprintf("timer %q has finished", name)}
+			}
+			if t.state.paused != (req.Cmd == "resume") {
+				return daemonResponse{Error: fmt.Sprintf("timer %q is already %sd", name, req.Cmd)}
+			}
+			t.state.togglePause(now)
+		case "add-time":
+			if t.finished {
+				return daemonResponse{Error: fmt.Sprintf("timer %q has finished", name)}
+			}
+			delta, err := parseDurationArg(req.Duration)
+			if err != nil {
+				return daemonResponse{Error: err.Error()}
+			}
+			t.state.addTime(delta, now)
+		case "stop":
+			delete(d.timers, name)
+			if !t.finished {
+				// Abandoned run - finished runs were recorded when they ended
+				d.metrics.runEnded(name, "quit", t.state.elapsed(now))
+				go recordHistory(t.state.summary(now, false))
+			}
+		}
+		return daemonResponse{OK: true, Timers: []Session{t.session(now)}}
+
+	default:
+		return daemonResponse{Error: fmt.Sprintf("unknown command %q", req.Cmd)}
+	}
+}
+
+func (d *daemon) start(req daemonRequest, now time.Time) daemonResponse {
+	var duration time.Duration
+	if req.Duration != "" {
+		var err error
+		duration, err = parseDurationArg(req.Duration)
+		if err != nil {
+			return daemonResponse{Error: err.Error()}
+		}
+		if duration <= 0 {
+			return daemonResponse{Error: "duration must be positive"}
+		}
+	}
+
+	name := req.Name
+	if name == "" {
+		d.nextID++
+		name = fmt.Sprintf("timer-%d", d.nextID)
+	}
+	if t, ok := d.timers[name]; ok && !t.finished {
+		return daemonResponse{Error: fmt.Sprintf("timer %q already exists", name)}
+	}
+
+	t := &daemonTimer{state: newTimerState(timerOptions{
+		duration: duration,
+		paused:   req.Paused,
+		name:     name,
+		alerts:   defaultAlerts,
+	}, now)}
+	d.timers[name] = t
+	return daemonResponse{OK: true, Timers: []Session{t.session(now)}}
+}
+
+// lookup finds a timer by name; an empty name selects the only timer
+func (d *daemon) lookup(name string) (string, *daemonTimer, error) {
+	if name == "" {
+		if len(d.timers) != 1 {
+			return "", nil, fmt.Errorf("%d timers running, specify a name", len(d.timers))
+		}
+		for only := range d.timers {
+			name = only
+		}
+	}
+	t, ok := d.timers[name]
+	if !ok {
+		return "", nil, fmt.Errorf("no timer named %q", name)
+	}
+	return name, t, nil
+}
+
+// sessions returns a snapshot of every timer, sorted by name
+func (d *daemon) sessions() []Session {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	names := make([]string, 0, len(d.timers))
+	for name := range d.timers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sessions := make([]Session, 0, len(names))
+	for _, name := range names {
+		sessions = append(sessions, d.timers[name].session(now))
+	}
+	return sessions
+}
+
+// interruptAll records the timers still running when the daemon shuts down
+// as abandoned runs
+func (d *daemon) interruptAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	for name, t := range d.timers {
+		if t.finished {
+			continue
+		}
+		d.metrics.runEnded(name, "interrupt", t.state.elapsed(now))
+		recordHistory(t.state.summary(now, false))
+	}
+}
+
+// recordHistory appends a daemon run to the history log, logging failures
+// since there is no terminal to report them to
+func recordHistory(summary TimerSummary) {
+	if err := appendHistory(summary); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record history: %v\n", err)
+	}
+}
+
+// notifyDaemon sends a notification, logging failures since there is no
+// summary to report them in
+func notifyDaemon(title, message string) {
+	for _, err := range notify(title, message) {
+		fmt.Fprintf(os.Stderr, "Warning: notification failed: %v\n", err)
+	}
+}
+
+// watch marks countdowns as finished when they run out and notifies the user
+func (d *daemon) watch(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(tickIntervalMedium)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case now := <-ticker.C:
+			d.mu.Lock()
+			for name, t := range d.timers {
+				if t.finished {
+					continue
+				}
+				if !t.state.done(now) {
+					for range t.state.dueAlerts(now) {
+						go notifyDaemon(name, fmt.Sprintf("%s remaining", formatHMS(t.state.remaining(now))))
+					}
+					continue
+				}
+				t.finished = true
+				t.finishedAt = now
+				d.metrics.runEnded(name, "finish", t.state.elapsed(now))
+				go notifyDaemon(name, "Timer finished!")
+				go recordHistory(t.state.summary(now, true))
+			}
+			d.mu.Unlock()
+		}
+	}
+}
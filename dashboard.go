@@ -0,0 +1,34 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// dashboardHTML is the single-page web dashboard served at "/"
+//
+//go:embed web/dashboard.html
+var dashboardHTML []byte
+
+// displayInfo is what the dashboard needs to draw the timer the way the
+// fullscreen TUI does
+type displayInfo struct {
+	Glyphs           map[string][]string `json:"glyphs"`
+	WarningThreshold float64             `json:"warningThreshold"` // seconds
+}
+
+func (api *timerAPI) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboardHTML)
+}
+
+func (api *timerAPI) handleDisplay(w http.ResponseWriter, r *http.Request) {
+	info := displayInfo{
+		Glyphs:           make(map[string][]string, len(glyphs)),
+		WarningThreshold: warningThreshold.Seconds(),
+	}
+	for ch, rows := range glyphs {
+		info.Glyphs[string(ch)] = rows
+	}
+	writeJSON(w, http.StatusOK, info)
+}
@@ -6,20 +6,30 @@ import (
 	"time"
 )
 
+// formatHMS formats a duration as MM:SS, HH:MM:SS from one hour, or with
+// a day field like "2d 03:04:05" from 24 hours
 func formatHMS(d time.Duration) string {
 	if d < 0 {
 		d = 0
 	}
-	total := int(d.Round(time.Second).Seconds())
-	h := total / 3600
-	m := (total % 3600) / 60
-	s := total % 60
+	days, h, m, s := splitDuration(d)
+	if days > 0 {
+		return fmt.Sprintf("%dd %02d:%02d:%02d", days, h, m, s)
+	}
 	if h > 0 {
 		return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
 	}
 	return fmt.Sprintf("%02d:%02d", m, s)
 }
 
+// formatAdjustment formats time added or removed with its sign, e.g. "+1m0s"
+func formatAdjustment(d time.Duration) string {
+	if d < 0 {
+		return "-" + (-d).String()
+	}
+	return "+" + d.String()
+}
+
 func renderBigTime(timeStr string, termWidth, termHeight int) string {
 	// Calculate if we can fit big text
 	totalWidth := len(timeStr)*(glyphWidth+glyphSpacing) - glyphSpacing
@@ -56,10 +66,7 @@ func centerText(text string, width, height int) string {
 	lines := strings.Split(text, "\n")
 
 	// Calculate vertical centering
-	vOffset := (height - len(lines)) / 2
-	if vOffset < 0 {
-		vOffset = 0
-	}
+	vOffset := centerOffset(height, len(lines))
 
 	// Pre-allocate builder capacity
 	var result strings.Builder
@@ -73,11 +80,7 @@ func centerText(text string, width, height int) string {
 
 	// Center each line horizontally
 	for _, line := range lines {
-		lineLen := len([]rune(line))
-		hOffset := (width - lineLen) / 2
-		if hOffset < 0 {
-			hOffset = 0
-		}
+		hOffset := centerOffset(width, len([]rune(line)))
 		result.WriteString(strings.Repeat(" ", hOffset))
 		result.WriteString(line)
 		result.WriteString("\n")
@@ -85,3 +88,63 @@ func centerText(text string, width, height int) string {
 
 	return result.String()
 }
+
+// frame describes the content of a single render of the timer
+type frame struct {
+	timeStr string
+	color   string         // ANSI color applied to the whole frame, empty for default
+	header  string         // optional line shown above the time (e.g. pomodoro phase)
+	footer  []string       // optional lines shown below the time (e.g. recent laps)
+	buttons []screenButton // clickable buttons under the time (fullscreen only)
+	prompt  string         // command prompt or its last message, drawn on the bottom line
+	overlay []string       // help drawn over the time, or as one line in inline mode
+}
+
+// renderFrame builds the full terminal output for a frame, and in
+// fullscreen mode where its parts were drawn for mouse hit-testing
+func renderFrame(f frame, useFullscreen bool) (string, frameLayout) {
+	if useFullscreen {
+		width, height := getTerminalSize()
+		layout := layoutFrame(f, width, height)
+		centeredText := centerText(strings.Join(layout.lines, "\n"), width, height)
+
+		// Apply color (paused = blue, <5min = red, else = default)
+		if f.color != "" {
+			centeredText = f.color + centeredText + resetStyle
+		}
+		output := clearScreen + moveCursor(1, 1) + fixNewlines(centeredText)
+		if len(f.overlay) > 0 {
+			output += drawOverlay(f.overlay, width, height)
+		}
+		if f.prompt != "" {
+			output += moveCursor(height, 1) + f.prompt
+		}
+		return output, layout
+	}
+
+	// Simple inline display
+	line := f.timeStr
+	if f.header != "" {
+		line = f.header + "  " + line
+	}
+	if len(f.overlay) > 0 {
+		// Clip the help to the terminal width so the line doesn't wrap
+		width, _ := getTerminalSize()
+		help := []rune(strings.Join(f.overlay, " "))
+		room := max(width-len([]rune(line))-3, 0)
+		if len(help) > room {
+			help = help[:room]
+		}
+		line += "  " + string(help)
+	} else if len(f.footer) > 0 {
+		line += "  | " + strings.Join(f.footer, " | ")
+	}
+	if f.prompt != "" {
+		// Keep the prompt out of the frame color
+		return fmt.Sprintf("\r%s%s%s  %s%s", f.color, line, resetStyle, f.prompt, clearToEOL), frameLayout{}
+	}
+	if f.color != "" {
+		return fmt.Sprintf("\r%s%s%s%s", f.color, line, resetStyle, clearToEOL), frameLayout{}
+	}
+	return fmt.Sprintf("\r%s%s", line, clearToEOL), frameLayout{}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Durations on the command line, in sequence files and in control requests
+// accept:
+//
+//	90, 1.5              plain numbers are seconds
+//	1:30, 01:30:00       clock notation (M:SS or H:MM:SS)
+//	2d4h, 1.5h, 500ms    numbers with units, as with time.ParseDuration
+//	90 min, 1h 30m       spaces between numbers, units and parts
+//	2 days 4 hours       long unit names
+//
+// A leading '+' or '-' gives the sign, e.g. for adding or removing time.
+
+// durationUnits maps unit names to their length
+var durationUnits = map[string]time.Duration{
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"ms": time.Millisecond, "msec": time.Millisecond, "millisecond": time.Millisecond, "milliseconds": time.Millisecond,
+	"us": time.Microsecond, "µs": time.Microsecond, "ns": time.Nanosecond,
+}
+
+// durationScanner walks a duration string, tracking 1-based columns for
+// error messages
+type durationScanner struct {
+	input []rune
+	pos   int
+}
+
+func (sc *durationScanner) errorf(col int, format string, args ...any) error {
+	return fmt.Errorf("invalid duration %q at column %d: %s", string(sc.input), col, fmt.Sprintf(format, args...))
+}
+
+func (sc *durationScanner) skipSpaces() {
+	for sc.pos < len(sc.input) && unicode.IsSpace(sc.input[sc.pos]) {
+		sc.pos++
+	}
+}
+
+// scan returns the run of runes at the current position matching accept
+func (sc *durationScanner) scan(accept func(rune) bool) string {
+	start := sc.pos
+	for sc.pos < len(sc.input) && accept(sc.input[sc.pos]) {
+		sc.pos++
+	}
+	return string(sc.input[start:sc.pos])
+}
+
+func isNumberRune(r rune) bool { return r >= '0' && r <= '9' || r == '.' }
+
+// parseDurationArg parses a duration in any of the forms above
+func parseDurationArg(arg string) (time.Duration, error) {
+	sc := &durationScanner{input: []rune(arg)}
+	sc.skipSpaces()
+	if sc.pos == len(sc.input) {
+		return 0, fmt.Errorf("invalid duration %q: empty", arg)
+	}
+
+	sign := time.Duration(1)
+	if r := sc.input[sc.pos]; r == '+' || r == '-' {
+		if r == '-' {
+			sign = -1
+		}
+		sc.pos++
+		sc.skipSpaces()
+	}
+
+	var d time.Duration
+	var err error
+	if strings.ContainsRune(arg, ':') {
+		d, err = sc.parseClock()
+	} else {
+		d, err = sc.parseUnits()
+	}
+	if err != nil {
+		return 0, err
+	}
+	return sign * d, nil
+}
+
+// parseClock parses M:SS or H:MM:SS, where the seconds may have decimals
+func (sc *durationScanner) parseClock() (time.Duration, error) {
+	var fields []string
+	var cols []int
+	for {
+		cols = append(cols, sc.pos+1)
+		fields = append(fields, sc.scan(isNumberRune))
+		if sc.pos == len(sc.input) || sc.input[sc.pos] != ':' {
+			break
+		}
+		sc.pos++
+	}
+	sc.skipSpaces()
+	if sc.pos < len(sc.input) {
+		return 0, sc.errorf(sc.pos+1, "unexpected %q in clock notation", string(sc.input[sc.pos:]))
+	}
+	if len(fields) > 3 {
+		return 0, sc.errorf(cols[3], "too many fields, expected M:SS or H:MM:SS")
+	}
+
+	var total float64
+	for i, field := range fields {
+		last := i == len(fields)-1
+		if field == "" {
+			return 0, sc.errorf(cols[i], "missing number")
+		}
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil || (!last && strings.Contains(field, ".")) {
+			return 0, sc.errorf(cols[i], "invalid number %q", field)
+		}
+		if i > 0 && value >= 60 {
+			return 0, sc.errorf(cols[i], "%q out of range, expected 00-59", field)
+		}
+		total = total*60 + value
+	}
+	return secondsToDuration(sc, total)
+}
+
+// parseUnits parses a plain number of seconds or a sequence of numbers with
+// units such as "2d 4h" or "90 min"
+func (sc *durationScanner) parseUnits() (time.Duration, error) {
+	var total float64
+	for parts := 0; ; parts++ {
+		sc.skipSpaces()
+		if sc.pos == len(sc.input) {
+			if parts == 0 {
+				return 0, sc.errorf(sc.pos+1, "missing number")
+			}
+			return secondsToDuration(sc, total)
+		}
+
+		col := sc.pos + 1
+		number := sc.scan(isNumberRune)
+		if number == "" {
+			return 0, sc.errorf(col, "expected a number, found %q", string(sc.input[sc.pos]))
+		}
+		value, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, sc.errorf(col, "invalid number %q", number)
+		}
+
+		sc.skipSpaces()
+		unitCol := sc.pos + 1
+		unit := strings.ToLower(sc.scan(unicode.IsLetter))
+		if unit == "" {
+			if parts == 0 && sc.pos == len(sc.input) {
+				// A plain number is seconds
+				return secondsToDuration(sc, value)
+			}
+			return 0, sc.errorf(unitCol, "missing unit after %q", number)
+		}
+		length, ok := durationUnits[unit]
+		if !ok {
+			return 0, sc.errorf(unitCol, "unknown unit %q (expected d, h, m, s or ms)", unit)
+		}
+		total += value * length.Seconds()
+	}
+}
+
+// durationFlag defines a command-line flag that takes any duration format,
+// like positional durations
+func durationFlag(name, usage string) *time.Duration {
+	d := new(time.Duration)
+	flag.Func(name, usage, func(arg string) error {
+		value, err := parseDurationArg(arg)
+		*d = value
+		return err
+	})
+	return d
+}
+
+// joinUnitWords joins command-line arguments that are only a unit name to
+// the argument before them, so "timer 90 min" reads as one duration.
+// Durations with several spaced parts still need quotes.
+func joinUnitWords(args []string) []string {
+	var joined []string
+	for _, arg := range args {
+		if _, ok := durationUnits[strings.ToLower(arg)]; ok && len(joined) > 0 {
+			joined[len(joined)-1] += " " + arg
+			continue
+		}
+		joined = append(joined, arg)
+	}
+	return joined
+}
+
+// secondsToDuration converts a parsed number of seconds, rejecting values
+// time.Duration cannot hold
+func secondsToDuration(sc *durationScanner, seconds float64) (time.Duration, error) {
+	ns := math.Round(seconds * float64(time.Second))
+	if ns >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %q: too long", string(sc.input))
+	}
+	return time.Duration(ns), nil
+}
+
+// splitDuration splits a duration into whole days, hours, minutes and
+// seconds, rounded to the second
+func splitDuration(d time.Duration) (days, hours, minutes, seconds int) {
+	total := int(d.Round(time.Second).Seconds())
+	return total / 86400, (total % 86400) / 3600, (total % 3600) / 60, total % 60
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDurationArg(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want time.Duration
+	}{
+		// Plain seconds
+		{"90", 90 * time.Second},
+		{"1.5", 1500 * time.Millisecond},
+		{" 45 ", 45 * time.Second},
+		{"0", 0},
+
+		// Clock notation
+		{"1:30", 90 * time.Second},
+		{"01:30:00", 90 * time.Minute},
+		{"0:05", 5 * time.Second},
+		{"1:00:00.5", time.Hour + 500*time.Millisecond},
+		{"100:00", 100 * time.Minute},
+
+		// Units
+		{"2d4h", 52 * time.Hour},
+		{"1.5h", 90 * time.Minute},
+		{"500ms", 500 * time.Millisecond},
+		{"90 min", 90 * time.Minute},
+		{"1h 30m", 90 * time.Minute},
+		{"2 days 4 hours", 52 * time.Hour},
+		{"1 Day", 24 * time.Hour},
+		{"0.5d", 12 * time.Hour},
+		{"250us", 250 * time.Microsecond},
+
+		// Signs
+		{"+5m", 5 * time.Minute},
+		{"-5m", -5 * time.Minute},
+		{"- 1:30", -90 * time.Second},
+		{"-30", -30 * time.Second},
+
+		// Near the limit of time.Duration
+		{"106751d", 106751 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := parseDurationArg(tt.arg)
+		if err != nil {
+			t.Errorf("parseDurationArg(%q): %v", tt.arg, err)
+		} else if got != tt.want {
+			t.Errorf("parseDurationArg(%q) = %v, want %v", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestParseDurationArgErrors(t *testing.T) {
+	tests := []struct {
+		arg, want string
+	}{
+		{"", "empty"},
+		{"  ", "empty"},
+		{"-", "missing number"},
+		{"5x", `unknown unit "x"`},
+		{"5 m 3", `missing unit after "3"`},
+		{"m5", `expected a number, found "m"`},
+		{"1.2.3s", `invalid number "1.2.3"`},
+		{"1:60", "out of range"},
+		{"1:2:3:4", "too many fields"},
+		{"1:", "missing number"},
+		{"1.5:00", `invalid number "1.5"`},
+		{"1:30 min", "unexpected"},
+		{"106752d", "too long"},
+		{"1e30", "unknown unit"},
+		{"99999999999999999999", "too long"},
+	}
+	for _, tt := range tests {
+		got, err := parseDurationArg(tt.arg)
+		if err == nil {
+			t.Errorf("parseDurationArg(%q) = %v, want an error", tt.arg, got)
+		} else if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseDurationArg(%q) error %q, want it to mention %q", tt.arg, err, tt.want)
+		}
+	}
+}
+
+func TestJoinUnitWords(t *testing.T) {
+	tests := []struct {
+		args, want []string
+	}{
+		{[]string{"90", "min"}, []string{"90 min"}},
+		{[]string{"1h", "30", "Minutes"}, []string{"1h", "30 Minutes"}},
+		{[]string{"min"}, []string{"min"}},
+		{[]string{"25m", "Focus"}, []string{"25m", "Focus"}},
+	}
+	for _, tt := range tests {
+		if got := joinUnitWords(tt.args); !slices.Equal(got, tt.want) {
+			t.Errorf("joinUnitWords(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -101,6 +101,24 @@ var glyphs = map[rune][]string{
 		"   ⬤⬤   ",
 		"        ",
 	},
+	'd': {
+		"      ⬤ ",
+		"      ⬤ ",
+		"   ⬤⬤⬤⬤ ",
+		"  ⬤   ⬤ ",
+		"  ⬤   ⬤ ",
+		"  ⬤   ⬤ ",
+		"   ⬤⬤⬤⬤ ",
+	},
+	'-': {
+		"        ",
+		"        ",
+		"        ",
+		"  ⬤⬤⬤⬤  ",
+		"        ",
+		"        ",
+		"        ",
+	},
 	' ': {
 		"        ",
 		"        ",
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Heatmap layout
+const (
+	heatmapLabelWidth = 4 // weekday label column ("Mon ")
+	heatmapCellWidth  = 2 // cell glyph plus spacing
+	heatmapMinWeeks   = 4 // below this the grid falls back to plain text
+	heatmapCell       = "■"
+)
+
+// heatmapLevels is the number of colored intensity levels above zero
+var heatmapLevels = len(heatmapRamp) - 1
+
+// dayKey returns the local calendar day of t as a map key
+func dayKey(t time.Time) string {
+	return t.Local().Format("2006-01-02")
+}
+
+// heatmapLevel maps a day's total onto 0 (nothing) .. heatmapLevels (max)
+func heatmapLevel(d, max time.Duration) int {
+	if d <= 0 || max <= 0 {
+		return 0
+	}
+	level := int((d*time.Duration(heatmapLevels) + max - 1) / max)
+	if level > heatmapLevels {
+		level = heatmapLevels
+	}
+	return level
+}
+
+// heatmapStart returns the Monday of the first of weeks weeks ending with
+// the week containing today
+func heatmapStart(today time.Time, weeks int) time.Time {
+	offset := (int(today.Weekday()) + 6) % 7
+	return time.Date(today.Year(), today.Month(), today.Day()-offset-7*(weeks-1), 0, 0, 0, 0, time.Local)
+}
+
+// renderHeatmap draws weeks columns of Monday-first day cells ending with
+// the week containing today
+func renderHeatmap(days map[string]time.Duration, today time.Time, weeks int) string {
+	first := heatmapStart(today, weeks)
+
+	var max time.Duration
+	for col := 0; col < weeks; col++ {
+		for row := 0; row < 7; row++ {
+			if d := days[dayKey(first.AddDate(0, 0, col*7+row))]; d > max {
+				max = d
+			}
+		}
+	}
+
+	var b strings.Builder
+
+	// Month labels above the first week of each month, skipped when they
+	// would overlap the previous label
+	labels := []byte(strings.Repeat(" ", heatmapLabelWidth+weeks*heatmapCellWidth+3))
+	lastMonth := time.Month(0)
+	nextFree := 0
+	for col := 0; col < weeks; col++ {
+		monday := first.AddDate(0, 0, col*7)
+		if monday.Month() == lastMonth {
+			continue
+		}
+		lastMonth = monday.Month()
+		pos := heatmapLabelWidth + col*heatmapCellWidth
+		if pos >= nextFree {
+			copy(labels[pos:], monday.Format("Jan"))
+			nextFree = pos + 4
+		}
+	}
+	b.WriteString(strings.TrimRight(string(labels), " "))
+	b.WriteString("\n")
+
+	weekdays := []string{"Mon", "", "Wed", "", "Fri", "", "Sun"}
+	for row := 0; row < 7; row++ {
+		fmt.Fprintf(&b, "%-*s", heatmapLabelWidth, weekdays[row])
+		for col := 0; col < weeks; col++ {
+			day := first.AddDate(0, 0, col*7+row)
+			if day.After(today) {
+				break
+			}
+			level := heatmapLevel(days[dayKey(day)], max)
+			b.WriteString(color256(heatmapRamp[level]) + heatmapCell + resetStyle + " ")
+		}
+		b.WriteString("\n")
+	}
+
+	// Legend
+	b.WriteString("\n" + strings.Repeat(" ", heatmapLabelWidth) + "Less ")
+	for _, c := range heatmapRamp {
+		b.WriteString(color256(c) + heatmapCell + resetStyle + " ")
+	}
+	b.WriteString("More\n")
+	return b.String()
+}
+
+// renderHeatmapText is the plain-text fallback for narrow or non-terminal
+// output: one line per week with its total
+func renderHeatmapText(days map[string]time.Duration, today time.Time, weeks int) string {
+	first := heatmapStart(today, weeks)
+
+	var b strings.Builder
+	for col := 0; col < weeks; col++ {
+		monday := first.AddDate(0, 0, col*7)
+		var total time.Duration
+		for row := 0; row < 7; row++ {
+			total += days[dayKey(monday.AddDate(0, 0, row))]
+		}
+		year, week := monday.ISOWeek()
+		fmt.Fprintf(&b, "%d-W%02d  %s\n", year, week, formatHMS(total))
+	}
+	return b.String()
+}
+
+// runHeatmap implements the 'timer heatmap' subcommand
+func runHeatmap(args []string) error {
+	fs := flag.NewFlagSet("heatmap", flag.ExitOnError)
+	name := fs.String("name", "", "only runs with this timer name")
+	weeks := fs.Int("weeks", 52, "number of weeks to show")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: timer heatmap [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Shows recorded time per day as a calendar heatmap.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *weeks < 1 || *weeks > 520 {
+		return fmt.Errorf("weeks must be between 1 and 520")
+	}
+
+	records, err := readHistory()
+	if err != nil {
+		return err
+	}
+
+	// Show as many weeks as fit; fall back to plain text when the grid
+	// would be too small to read or output is not a terminal
+	shown := *weeks
+	width, _ := getTerminalSize()
+	if fit := (width - heatmapLabelWidth) / heatmapCellWidth; fit < shown {
+		shown = fit
+	}
+	plain := shown < heatmapMinWeeks || !term.IsTerminal(int(os.Stdout.Fd()))
+	if plain {
+		shown = *weeks
+	}
+
+	days := make(map[string]time.Duration)
+	var total time.Duration
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	first := heatmapStart(today, shown)
+	for _, r := range records {
+		if *name != "" && r.Name != *name {
+			continue
+		}
+		if r.Start.Before(first) {
+			continue
+		}
+		d := parseFormattedDuration(r.Duration)
+		days[dayKey(r.Start)] += d
+		total += d
+	}
+
+	if plain {
+		fmt.Print(renderHeatmapText(days, today, shown))
+	} else {
+		fmt.Print(renderHeatmap(days, today, shown))
+	}
+
+	title := "all timers"
+	if *name != "" {
+		title = *name
+	}
+	fmt.Printf("\n%s: %s recorded in the last %d weeks\n", title, formatHMS(total), shown)
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// helpDetails returns the timer details shown in the help overlay as
+// label and value pairs
+func helpDetails(st *timerState, now time.Time) [][2]string {
+	name := st.name
+	if name == "" {
+		name = "(unnamed)"
+	}
+	details := [][2]string{
+		{"Name", name},
+		{"Started", formatWallClock(st.runStart, now)},
+	}
+
+	// Projected end of the countdown (or current phase) if it keeps running
+	if !st.isCounter() {
+		label := "Ends"
+		if st.plan != nil {
+			label = "Phase ends"
+		}
+		end := now.Add(st.duration - st.elapsed(now))
+		switch {
+		case st.paused:
+			details = append(details, [2]string{label, "paused, " + formatHMS(st.remaining(now)) + " left"})
+		case end.Before(now):
+			details = append(details, [2]string{"Ended", formatWallClock(end, now)})
+		default:
+			details = append(details, [2]string{label, formatWallClock(end, now)})
+		}
+	}
+
+	details = append(details, [2]string{"Paused", formatHMS(st.pausedTotal(now))})
+	config := configFile
+	if config == "" {
+		config = "none, using defaults"
+	}
+	return append(details, [2]string{"Config", config})
+}
+
+// boundKeys returns the actions that have keys, with their keys. A non-nil
+// available limits them to the actions that work in the current view.
+func boundKeys(available []string) ([]keyAction, [][]string) {
+	var actions []keyAction
+	var keys [][]string
+	for _, action := range keyActions {
+		if available != nil && !slices.Contains(available, action.name) {
+			continue
+		}
+		var names []string
+		for _, key := range keymap.keys[action.name] {
+			names = append(names, string(key))
+		}
+		if len(names) > 0 {
+			actions = append(actions, action)
+			keys = append(keys, names)
+		}
+	}
+	return actions, keys
+}
+
+// helpOverlay returns the lines of the help overlay: the key bindings of
+// the available actions (nil for all) and the timer details. Inline mode
+// gets a single compact line.
+func helpOverlay(st *timerState, now time.Time, fullscreen bool, available []string) []string {
+	actions, keys := boundKeys(available)
+	if !fullscreen {
+		// The details first, then the first key of each action and the
+		// action name, as the line is clipped to the terminal width
+		var parts []string
+		for _, d := range helpDetails(st, now) {
+			parts = append(parts, strings.ToLower(d[0])+" "+d[1])
+		}
+		var bindings []string
+		for i, action := range actions {
+			bindings = append(bindings, keys[i][0]+" "+action.name)
+		}
+		return []string{strings.Join(append(parts, strings.Join(bindings, ", ")), " | ")}
+	}
+
+	title := "Help"
+	if k := keymap.keys["help"]; len(k) > 0 {
+		title += fmt.Sprintf(" (press %s to close)", k[0])
+	}
+	lines := []string{title, ""}
+	for i, action := range actions {
+		lines = append(lines, fmt.Sprintf("%-12s %s", strings.Join(keys[i], " "), action.describe()))
+	}
+	if available == nil || slices.Contains(available, "command") {
+		lines = append(lines, fmt.Sprintf("%-12s %s", "Commands", strings.Join(paletteCommands, " ")))
+	}
+	lines = append(lines, "")
+	for _, d := range helpDetails(st, now) {
+		lines = append(lines, fmt.Sprintf("%-12s %s", d[0], d[1]))
+	}
+	return lines
+}
+
+// drawOverlay draws lines in a box centered on a width x height screen,
+// over whatever is there, clipping lines that don't fit
+func drawOverlay(lines []string, width, height int) string {
+	inner := 0
+	for _, line := range lines {
+		inner = max(inner, len([]rune(line)))
+	}
+	inner = max(min(inner, width-4), 0)
+	top := centerOffset(height, len(lines)+2) + 1
+	left := centerOffset(width, inner+4) + 1
+
+	var b strings.Builder
+	border := strings.Repeat("─", inner+2)
+	b.WriteString(moveCursor(top, left) + "┌" + border + "┐")
+	for i, line := range lines {
+		if top+1+i >= height {
+			break // Keep the bottom line free for the prompt
+		}
+		runes := []rune(line)
+		if len(runes) > inner {
+			runes = runes[:inner]
+		}
+		padding := strings.Repeat(" ", inner-len(runes))
+		b.WriteString(moveCursor(top+1+i, left) + "│ " + string(runes) + padding + " │")
+	}
+	if bottom := top + 1 + len(lines); bottom < height {
+		b.WriteString(moveCursor(bottom, left) + "└" + border + "┘")
+	}
+	return b.String()
+}
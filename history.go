@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// HistoryRecord is one completed or abandoned run in the history log.
+// Timestamps are RFC 3339 so records stay unambiguous across time zones.
+type HistoryRecord struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Duration string    `json:"duration"` // effective run time, excluding pauses
+	Paused   string    `json:"paused"`   // total paused time
+	Mode     string    `json:"mode"`
+	Finished bool      `json:"finished"`
+	Name     string    `json:"name,omitempty"`
+	Adjusted string    `json:"adjusted,omitempty"` // net time added or removed while running
+	Early    bool      `json:"early,omitempty"`    // finished with the finish key before running out
+	Restarts int       `json:"restarts,omitempty"` // resets and restarts
+	Focused  string    `json:"focused,omitempty"`  // time in work phases (pomodoro)
+}
+
+func historyRecordFromSummary(summary TimerSummary) HistoryRecord {
+	record := HistoryRecord{
+		Start:    summary.Start,
+		End:      summary.End,
+		Duration: formatDuration(summary.Duration),
+		Paused:   formatDuration(summary.Paused),
+		Mode:     summary.Mode,
+		Finished: summary.Finished,
+		Name:     summary.Name,
+		Early:    summary.Early,
+		Restarts: len(summary.Restarts),
+	}
+	if len(summary.Adjustments) > 0 {
+		record.Adjusted = formatDuration(netAdjustment(summary.Adjustments))
+	}
+	if summary.Mode == "pomodoro" {
+		var focused time.Duration
+		for _, p := range summary.Phases {
+			if p.Phase == phaseWork {
+				focused += parseFormattedDuration(p.Elapsed)
+			}
+		}
+		record.Focused = formatDuration(focused)
+	}
+	return record
+}
+
+// focusedTime returns the focused time of a run: its work phases for a
+// pomodoro run, its effective run time otherwise
+func (r HistoryRecord) focusedTime() time.Duration {
+	if r.Focused != "" {
+		return parseFormattedDuration(r.Focused)
+	}
+	return parseFormattedDuration(r.Duration)
+}
+
+// historyDir returns the history directory under the XDG data dir
+func historyDir() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate data dir: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "go-timer"), nil
+}
+
+// historyFiles returns the rotated history files oldest first, followed by
+// the current file (history.jsonl.N ... history.jsonl.1, history.jsonl)
+func historyFiles(dir string) []string {
+	var files []string
+	for i := historyKeep; i >= 1; i-- {
+		path := filepath.Join(dir, fmt.Sprintf("history.jsonl.%d", i))
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return append(files, filepath.Join(dir, "history.jsonl"))
+}
+
+// lockHistory takes an exclusive lock shared by every timer process writing
+// to the history directory and returns the function releasing it
+func lockHistory(dir string) (func(), error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history dir: %w", err)
+	}
+	lock, err := os.OpenFile(filepath.Join(dir, "history.lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history lock: %w", err)
+	}
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("failed to lock history: %w", err)
+	}
+	return func() { lock.Close() }, nil
+}
+
+// rotateHistory shifts history.jsonl to history.jsonl.1 (and older files up
+// by one, dropping the oldest) once it reaches historyMaxSize
+func rotateHistory(dir string) error {
+	current := filepath.Join(dir, "history.jsonl")
+	info, err := os.Stat(current)
+	if err != nil || info.Size() < historyMaxSize {
+		return nil
+	}
+	for i := historyKeep - 1; i >= 1; i-- {
+		from := filepath.Join(dir, fmt.Sprintf("history.jsonl.%d", i))
+		to := filepath.Join(dir, fmt.Sprintf("history.jsonl.%d", i+1))
+		if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate history: %w", err)
+		}
+	}
+	if err := os.Rename(current, filepath.Join(dir, "history.jsonl.1")); err != nil {
+		return fmt.Errorf("failed to rotate history: %w", err)
+	}
+	return nil
+}
+
+// appendHistory appends a finished run to the history log
+func appendHistory(summary TimerSummary) error {
+	dir, err := historyDir()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockHistory(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := rotateHistory(dir); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(historyRecordFromSummary(summary))
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "history.jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return f.Close()
+}
+
+// readHistoryFile reads the records of a single history file, returning the
+// number of lines that could not be parsed
+func readHistoryFile(path string) ([]HistoryRecord, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer f.Close()
+
+	var records []HistoryRecord
+	invalid := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record HistoryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Start.IsZero() {
+			invalid++
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return records, invalid, nil
+}
+
+// readHistory reads every record from the current and rotated history files,
+// oldest file first. Malformed lines are skipped.
+func readHistory() ([]HistoryRecord, error) {
+	dir, err := historyDir()
+	if err != nil {
+		return nil, err
+	}
+	var records []HistoryRecord
+	for _, path := range historyFiles(dir) {
+		fileRecords, _, err := readHistoryFile(path)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, fileRecords...)
+	}
+	return records, nil
+}
+
+// compactHistory merges the rotated files into a single sorted history.jsonl,
+// dropping malformed lines, duplicates and records that started before cutoff
+func compactHistory(cutoff time.Time) error {
+	dir, err := historyDir()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockHistory(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	files := historyFiles(dir)
+	var records []HistoryRecord
+	invalid := 0
+	for _, path := range files {
+		fileRecords, fileInvalid, err := readHistoryFile(path)
+		if err != nil {
+			return err
+		}
+		records = append(records, fileRecords...)
+		invalid += fileInvalid
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Start.Before(records[j].Start)
+	})
+
+	// Records are compared by their encoded form, since parsed times with
+	// the same instant may carry different locations
+	seen := make(map[string]bool)
+	kept := records[:0]
+	duplicates, old := 0, 0
+	for _, record := range records {
+		if !cutoff.IsZero() && record.Start.Before(cutoff) {
+			old++
+			continue
+		}
+		key, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if seen[string(key)] {
+			duplicates++
+			continue
+		}
+		seen[string(key)] = true
+		kept = append(kept, record)
+	}
+
+	// Write to a temporary file and rename it into place so a crash never
+	// leaves a truncated history behind
+	tmp, err := os.CreateTemp(dir, "history.jsonl.tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(w)
+	for _, record := range kept {
+		if err := encoder.Encode(record); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, "history.jsonl")); err != nil {
+		return fmt.Errorf("failed to replace history: %w", err)
+	}
+	for _, path := range files[:len(files)-1] {
+		os.Remove(path)
+	}
+
+	fmt.Printf("compacted %d files into %d records (dropped %d invalid, %d duplicate, %d old)\n",
+		len(files), len(kept), invalid, duplicates, old)
+	return nil
+}
+
+// runHistory implements the 'timer history' subcommand
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	before := fs.String("before", "", "compact: drop records that started before this date (YYYY-MM-DD)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: timer history [options] <command>\n\n")
+		fmt.Fprintf(os.Stderr, "Commands:\n")
+		fmt.Fprintf(os.Stderr, "  path       print the history file location\n")
+		fmt.Fprintf(os.Stderr, "  compact    merge rotated files, drop invalid and duplicate records\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	switch fs.Arg(0) {
+	case "path":
+		dir, err := historyDir()
+		if err != nil {
+			return err
+		}
+		fmt.Println(filepath.Join(dir, "history.jsonl"))
+		return nil
+	case "compact":
+		var cutoff time.Time
+		if *before != "" {
+			var err error
+			cutoff, err = time.ParseInLocation("2006-01-02", *before, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", *before)
+			}
+		}
+		return compactHistory(cutoff)
+	default:
+		return fmt.Errorf("unknown history command %q", fs.Arg(0))
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Hook events that can be configured in the "hooks" section of config.json
+var hookEvents = []string{"start", "pause", "resume", "warning", "finish", "quit", "interrupt"}
+
+// hookRunner runs the configured hook commands in the background so a slow
+// hook can never block the timer loop
+type hookRunner struct {
+	wg sync.WaitGroup
+}
+
+// fire runs the hook configured for event (if any) with the timer state
+// described in TIMER_* environment variables
+func (h *hookRunner) fire(event string, st *timerState, now time.Time, finished bool) {
+	command := hooks[event]
+	if command == "" {
+		return
+	}
+
+	// Snapshot the state now; the command runs after the loop moved on
+	env := append(os.Environ(),
+		"TIMER_EVENT="+event,
+		"TIMER_NAME="+st.name,
+		"TIMER_MODE="+st.mode(),
+		"TIMER_ELAPSED="+strconv.Itoa(int(st.elapsed(now).Round(time.Second).Seconds())),
+		"TIMER_FINISHED="+strconv.FormatBool(finished),
+	)
+	if !st.isCounter() {
+		env = append(env,
+			"TIMER_DURATION="+strconv.Itoa(int(st.duration.Round(time.Second).Seconds())),
+			"TIMER_REMAINING="+strconv.Itoa(int(st.remaining(now).Round(time.Second).Seconds())),
+		)
+	}
+	if st.plan != nil {
+		label, _ := st.plan.phase()
+		env = append(env,
+			"TIMER_PHASE="+label,
+			"TIMER_CYCLE="+strconv.Itoa(st.plan.cycle()),
+		)
+	}
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Env = env
+		// Run in its own process group so a timeout kills the whole hook,
+		// not just the shell
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		cmd.Cancel = func() error {
+			return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+		// Output is discarded (stdin/stdout/stderr stay nil) so hooks
+		// cannot draw over the TUI
+		cmd.Run()
+	}()
+}
+
+// wait blocks until running hooks complete; each is bounded by hookTimeout
+func (h *hookRunner) wait() {
+	h.wg.Wait()
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timerCommand is a control request handed from the HTTP API to the timer
+// loop, which owns the timer state
+type timerCommand struct {
+	action string        // pause, resume, add-time or stop
+	delta  time.Duration // for add-time
+	reply  chan timerCommandResult
+}
+
+type timerCommandResult struct {
+	session Session
+	err     error
+}
+
+// hubEvent is a state snapshot published by the timer loop
+type hubEvent struct {
+	name    string // "state" for changes, "tick" for display updates
+	session Session
+}
+
+// stateHub keeps the latest published session and fans events out to
+// Server-Sent Events subscribers
+type stateHub struct {
+	mu      sync.Mutex
+	session Session
+	subs    map[chan hubEvent]struct{}
+	closed  bool
+	active  sync.WaitGroup // subscribers that have not unsubscribed yet
+}
+
+func newStateHub() *stateHub {
+	return &stateHub{subs: make(map[chan hubEvent]struct{})}
+}
+
+// publish stores the session and sends it to every subscriber, dropping
+// the event for subscribers that fall behind
+func (h *stateHub) publish(name string, session Session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.session = session
+	for ch := range h.subs {
+		select {
+		case ch <- hubEvent{name: name, session: session}:
+		default:
+		}
+	}
+}
+
+func (h *stateHub) latest() Session {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.session
+}
+
+// subscribe registers a new subscriber; the channel is closed when the hub
+// closes. Every subscriber must call unsubscribe once done.
+func (h *stateHub) subscribe() chan hubEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.active.Add(1)
+	ch := make(chan hubEvent, 16)
+	if h.closed {
+		close(ch)
+		return ch
+	}
+	h.subs[ch] = struct{}{}
+	return ch
+}
+
+func (h *stateHub) unsubscribe(ch chan hubEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+	h.active.Done()
+}
+
+// close ends every subscription when the timer exits, giving subscribers
+// a moment to deliver the events still queued (such as the final state)
+func (h *stateHub) close() {
+	h.mu.Lock()
+	h.closed = true
+	for ch := range h.subs {
+		delete(h.subs, ch)
+		close(ch)
+	}
+	h.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		h.active.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+	}
+}
+
+// timerAPI serves the HTTP API of a running timer
+type timerAPI struct {
+	hub        *stateHub
+	cmdCh      chan<- timerCommand
+	listenHost string // host name given in the listen address, if any
+}
+
+// startHTTP starts the HTTP API and web dashboard listener on addr in the
+// background
+func startHTTP(addr string, hub *stateHub, cmdCh chan<- timerCommand, metrics *timerMetrics) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start HTTP API: %w", err)
+	}
+
+	listenHost, _, _ := net.SplitHostPort(addr)
+	api := &timerAPI{hub: hub, cmdCh: cmdCh, listenHost: listenHost}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", api.handleDashboard)
+	mux.HandleFunc("GET /api/display", api.handleDisplay)
+	mux.HandleFunc("GET /api/session", api.handleSession)
+	mux.HandleFunc("GET /api/events", api.handleEvents)
+	mux.HandleFunc("POST /api/pause", api.handleCommand("pause"))
+	mux.HandleFunc("POST /api/resume", api.handleCommand("resume"))
+	mux.HandleFunc("POST /api/add-time", api.handleCommand("add-time"))
+	mux.HandleFunc("POST /api/stop", api.handleCommand("stop"))
+	mux.HandleFunc("GET /metrics", metricsHandler(metrics, func() []Session {
+		return []Session{hub.latest()}
+	}))
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	return server, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (api *timerAPI) handleSession(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.hub.latest())
+}
+
+// controlHeader must be sent with control requests. Browsers only let a
+// page send a custom header to its own origin, so other web pages cannot
+// control the timer through the listener.
+const controlHeader = "X-Timer-Control"
+
+// checkControl rejects control requests without controlHeader, from a
+// browser page served by another origin, or addressed to a host name other
+// than localhost or the one listened on. A page whose name was rebound to
+// this address sends its own name as both Origin and Host.
+func (api *timerAPI) checkControl(r *http.Request) error {
+	if r.Header.Get(controlHeader) == "" {
+		return fmt.Errorf("missing %s header", controlHeader)
+	}
+	if !api.allowedHost(r.Host) {
+		return fmt.Errorf("unexpected host %q", r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return fmt.Errorf("cross-origin request from %s", origin)
+		}
+	}
+	return nil
+}
+
+// allowedHost reports whether a request's Host header names this listener:
+// an IP address, localhost or the host name of the listen address
+func (api *timerAPI) allowedHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport // No port
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") {
+		return true
+	}
+	return api.listenHost != "" && strings.EqualFold(host, api.listenHost)
+}
+
+// handleCommand forwards a control action to the timer loop and responds
+// with the resulting session
+func (api *timerAPI) handleCommand(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := api.checkControl(r); err != nil {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+		cmd := timerCommand{action: action, reply: make(chan timerCommandResult, 1)}
+		if action == "add-time" {
+			// Duration from ?duration=2m or a JSON body {"duration": "2m"}
+			arg := r.URL.Query().Get("duration")
+			if arg == "" {
+				var body struct {
+					Duration string `json:"duration"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeError(w, http.StatusBadRequest, fmt.Errorf("missing duration"))
+					return
+				}
+				arg = body.Duration
+			}
+			delta, err := parseDurationArg(arg)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			cmd.delta = delta
+		}
+
+		select {
+		case api.cmdCh <- cmd:
+		case <-r.Context().Done():
+			return
+		case <-time.After(5 * time.Second):
+			writeError(w, http.StatusServiceUnavailable, fmt.Errorf("timer is not responding"))
+			return
+		}
+		res := <-cmd.reply
+		if res.err != nil {
+			writeError(w, http.StatusConflict, res.err)
+			return
+		}
+		writeJSON(w, http.StatusOK, res.session)
+	}
+}
+
+// handleEvents streams "state" and "tick" events as Server-Sent Events,
+// starting with the current state
+func (api *timerAPI) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	events := api.hub.subscribe()
+	defer api.hub.unsubscribe(events)
+
+	send := func(e hubEvent) bool {
+		data, err := json.Marshal(e.session)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	if !send(hubEvent{name: "state", session: api.hub.latest()}) {
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok || !send(e) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// keyEvent is a decoded key press, named like "q", "space", "ctrl+c", "up",
+// "pgdown" or "shift+f5". Printable characters are named by themselves.
+type keyEvent string
+
+// Keys named by words rather than the character they type
+var namedKeys = []string{
+	"space", "enter", "tab", "backspace", "esc",
+	"up", "down", "left", "right", "home", "end", "pgup", "pgdown", "insert", "delete",
+	"f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9", "f10", "f11", "f12",
+}
+
+// Ctrl+letter keys that send the same byte as a named key
+var ctrlAliases = map[string]string{"h": "backspace", "i": "tab", "j": "enter", "m": "enter"}
+
+// Modifiers in the order they appear in key names
+var keyModifiers = []string{"ctrl", "alt", "shift"}
+
+// Final bytes of CSI and SS3 sequences for keys without parameters
+var escapeFinals = map[byte]string{
+	'A': "up", 'B': "down", 'C': "right", 'D': "left", 'H': "home", 'F': "end",
+	'P': "f1", 'Q': "f2", 'R': "f3", 'S': "f4",
+}
+
+// Parameters of "CSI n ~" sequences
+var tildeKeys = map[int]string{
+	1: "home", 2: "insert", 3: "delete", 4: "end", 5: "pgup", 6: "pgdown", 7: "home", 8: "end",
+	11: "f1", 12: "f2", 13: "f3", 14: "f4", 15: "f5", 17: "f6", 18: "f7", 19: "f8",
+	20: "f9", 21: "f10", 23: "f11", 24: "f12",
+}
+
+// parseKey decodes accumulated input bytes. It reports false while a
+// sequence is incomplete, and an empty event for ignored input such as
+// mouse reports or unknown sequences.
+func parseKey(seq []byte) (keyEvent, bool) {
+	if len(seq) == 0 {
+		return "", false
+	}
+	if seq[0] != 0x1b {
+		if seq[0] >= 0x80 {
+			// UTF-8 character
+			if !utf8.FullRune(seq) {
+				return "", false
+			}
+			r, _ := utf8.DecodeRune(seq)
+			return keyEvent(string(r)), true
+		}
+		return controlKey(seq[0]), true
+	}
+	if len(seq) == 1 {
+		return "", false // Wait for more or timeout
+	}
+
+	switch seq[1] {
+	case '[':
+	case 'O':
+		// SS3: arrows in application cursor mode and F1-F4
+		if len(seq) < 3 {
+			return "", false
+		}
+		return keyEvent(escapeFinals[seq[2]]), true
+	default:
+		// Alt+key, named "ctrl+alt+x" for Ctrl+Alt+x
+		key := controlKey(seq[1])
+		if rest, ok := strings.CutPrefix(string(key), "ctrl+"); ok {
+			return keyEvent("ctrl+alt+" + rest), true
+		}
+		if key != "" {
+			key = "alt+" + key
+		}
+		return key, true
+	}
+
+	if len(seq) < 3 {
+		return "", false
+	}
+	last := seq[len(seq)-1]
+	switch seq[2] {
+	case 'M':
+		// X10 mouse: \033[M followed by three bytes
+		return "", len(seq) >= 6 // Ignore mouse
+	case '<':
+		// Extended mouse ends with 'm' or 'M'
+		return "", last == 'm' || last == 'M' // Ignore mouse
+	}
+	if last < 0x40 || last > 0x7E {
+		return "", false // Incomplete, continue
+	}
+	return csiKey(string(seq[2:len(seq)-1]), last), true
+}
+
+// controlKey names a single-byte key
+func controlKey(b byte) keyEvent {
+	switch {
+	case b == ' ':
+		return "space"
+	case b == '\r' || b == '\n':
+		return "enter"
+	case b == '\t':
+		return "tab"
+	case b == 0x7f || b == 0x08:
+		return "backspace"
+	case b == 0x1b:
+		return "esc"
+	case b >= 0x01 && b <= 0x1a:
+		return keyEvent("ctrl+" + string(rune('a'+b-1)))
+	case b > ' ' && b < 0x7f:
+		return keyEvent(string(rune(b)))
+	}
+	return ""
+}
+
+// csiKey names a CSI sequence from its parameters and final byte, e.g.
+// "1;5" and 'C' for Ctrl+Right or "15" and '~' for F5
+func csiKey(params string, final byte) keyEvent {
+	fields := strings.Split(params, ";")
+	var key string
+	if final == '~' {
+		n, _ := strconv.Atoi(fields[0])
+		key = tildeKeys[n]
+	} else if final == 'Z' {
+		return "shift+tab"
+	} else {
+		key = escapeFinals[final]
+	}
+	if key == "" {
+		return ""
+	}
+	modifier := 1
+	if len(fields) > 1 {
+		modifier, _ = strconv.Atoi(fields[1])
+	}
+	return withModifiers(keyEvent(key), modifier)
+}
+
+// withModifiers prefixes a key with the modifiers of an xterm modifier
+// parameter (1 + shift 1, alt 2, ctrl 4)
+func withModifiers(key keyEvent, modifier int) keyEvent {
+	bits := max(modifier-1, 0)
+	var prefix string
+	if bits&4 != 0 {
+		prefix += "ctrl+"
+	}
+	if bits&2 != 0 {
+		prefix += "alt+"
+	}
+	if bits&1 != 0 {
+		prefix += "shift+"
+	}
+	return keyEvent(prefix) + key
+}
+
+// normalizeKeyName checks a key name from the config and returns it in the
+// form parseKey produces, e.g. "Ctrl+Shift+Up" becomes "ctrl+shift+up"
+func normalizeKeyName(name string) (keyEvent, error) {
+	// The last '+' separates modifiers, unless it is the key itself
+	var modifiers []string
+	key := name
+	if i := strings.LastIndex(name[:max(len(name)-1, 0)], "+"); i >= 0 {
+		modifiers, key = strings.Split(name[:i], "+"), name[i+1:]
+	}
+
+	seen := map[string]bool{}
+	for _, m := range modifiers {
+		m = strings.ToLower(m)
+		if !slices.Contains(keyModifiers, m) {
+			return "", fmt.Errorf("unknown modifier %q in key %q", m, name)
+		}
+		seen[m] = true
+	}
+
+	switch {
+	case key == " ":
+		key = "space"
+	case utf8.RuneCountInString(key) == 1:
+		// Terminals send Shift+a as "A" and only have Ctrl+letter
+		if seen["shift"] {
+			return "", fmt.Errorf("invalid key %q, use the shifted character instead", name)
+		}
+		if seen["ctrl"] {
+			key = strings.ToLower(key)
+			if key < "a" || key > "z" {
+				return "", fmt.Errorf("invalid key %q, ctrl only combines with letters", name)
+			}
+			if same, ok := ctrlAliases[key]; ok {
+				return "", fmt.Errorf("invalid key %q, terminals send it as %s", name, same)
+			}
+		}
+	default:
+		key = strings.ToLower(key)
+		if !slices.Contains(namedKeys, key) {
+			return "", fmt.Errorf("unknown key %q", name)
+		}
+	}
+
+	var prefix string
+	for _, m := range keyModifiers {
+		if seen[m] {
+			prefix += m + "+"
+		}
+	}
+	return keyEvent(prefix + key), nil
+}
+
+// keyAction is something a key can be bound to
+type keyAction struct {
+	name        string
+	description string
+	keys        []string // default keys
+}
+
+// keyActions lists the bindable actions in the order 'timer keys' shows them
+var keyActions = []keyAction{
+	{"pause", "pause or resume", []string{"space"}},
+	{"lap", "record a lap (stopwatch)", []string{"l", "L"}},
+	{"skip", "skip to the next phase", []string{"n", "N"}},
+	{"reset", "start over, paused", []string{"r"}},
+	{"restart", "start over, running", []string{"R"}},
+	{"finish", "finish now, marked as finished", []string{"f"}},
+	{"add-time", "add time", []string{"up", "+", "="}},
+	{"remove-time", "remove time", []string{"down", "-"}},
+	{"add-time-small", "add time", []string{"right"}},
+	{"remove-time-small", "remove time", []string{"left"}},
+	{"command", "open the command prompt", []string{":"}},
+	{"help", "show or hide this help", []string{"?"}},
+	{"quit", "quit (detach in timer attach)", []string{"q", "Q", "esc"}},
+	{"interrupt", "force quit", []string{"ctrl+c"}},
+}
+
+// describe returns the action's description, with the time added or
+// removed by the adjustments, as adjustStep comes from the config
+func (a keyAction) describe() string {
+	switch d := actionAdjustment(a.name); {
+	case d > 0:
+		return "add " + shortDuration(d)
+	case d < 0:
+		return "remove " + shortDuration(-d)
+	}
+	return a.description
+}
+
+// keyBindings maps keys to actions, and keeps the keys of each action in
+// the order they were given
+type keyBindings struct {
+	action map[keyEvent]string
+	keys   map[string][]keyEvent
+}
+
+// keymap holds the key bindings in effect; replaced from the config's "keys"
+var keymap, _ = buildKeymap(nil)
+
+// keymapError is the problem found in the config's "keys", if any, in
+// which case the default keymap is used
+var keymapError error
+
+// buildKeymap maps keys to actions, starting from the defaults. An action
+// given in bindings replaces all of its default keys; an empty list
+// unbinds it. Two actions sharing a key is an error.
+func buildKeymap(bindings map[string][]string) (keyBindings, error) {
+	for action := range bindings {
+		if !slices.ContainsFunc(keyActions, func(a keyAction) bool { return a.name == action }) {
+			return keyBindings{}, fmt.Errorf("unknown action %q", action)
+		}
+	}
+
+	m := keyBindings{action: make(map[keyEvent]string), keys: make(map[string][]keyEvent)}
+	for _, action := range keyActions {
+		names, ok := bindings[action.name]
+		if !ok {
+			names = action.keys
+		}
+		for _, name := range names {
+			key, err := normalizeKeyName(name)
+			if err != nil {
+				return keyBindings{}, fmt.Errorf("%s: %w", action.name, err)
+			}
+			if other, taken := m.action[key]; taken {
+				if other == action.name {
+					continue
+				}
+				return keyBindings{}, fmt.Errorf("key %q is bound to both %s and %s", key, other, action.name)
+			}
+			m.action[key] = action.name
+			m.keys[action.name] = append(m.keys[action.name], key)
+		}
+	}
+	return m, nil
+}
+
+// actionAdjustment returns the time added (or removed) by an adjustment
+// action
+func actionAdjustment(action string) time.Duration {
+	switch action {
+	case "add-time":
+		return adjustStep
+	case "remove-time":
+		return -adjustStep
+	case "add-time-small":
+		return adjustFineStep
+	case "remove-time-small":
+		return -adjustFineStep
+	}
+	return 0
+}
+
+// shortDuration formats a whole duration compactly, e.g. "1m" or "1h30m"
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// runKeys implements 'timer keys', printing the effective keymap
+func runKeys(args []string) error {
+	fs := flag.NewFlagSet("keys", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: timer keys\n\n")
+		fmt.Fprintf(os.Stderr, "Print the key bindings in effect, including the \"keys\" section of config.json.\n")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	fmt.Printf("%-18s %-24s %s\n", "ACTION", "KEYS", "DESCRIPTION")
+	for _, action := range keyActions {
+		var names []string
+		for _, key := range keymap.keys[action.name] {
+			names = append(names, string(key))
+		}
+		keys := strings.Join(names, " ")
+		if keys == "" {
+			keys = "(unbound)"
+		}
+		fmt.Printf("%-18s %-24s %s\n", action.name, keys, action.describe())
+	}
+	if keymapError != nil {
+		return fmt.Errorf("invalid \"keys\" in config, using the defaults: %w", keymapError)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import "testing"
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		seq  string
+		want keyEvent
+		done bool
+	}{
+		// Single bytes
+		{"q", "q", true},
+		{"Q", "Q", true},
+		{" ", "space", true},
+		{"\r", "enter", true},
+		{"\t", "tab", true},
+		{"\x7f", "backspace", true},
+		{"\x03", "ctrl+c", true},
+		{"é", "é", true},
+
+		// Incomplete input
+		{"", "", false},
+		{"\x1b", "", false},
+		{"\x1b[", "", false},
+		{"\x1b[1;5", "", false},
+		{"\x1bO", "", false},
+		{"\xc3", "", false},
+
+		// Alt
+		{"\x1bx", "alt+x", true},
+		{"\x1b\x01", "ctrl+alt+a", true},
+
+		// CSI and SS3
+		{"\x1b[A", "up", true},
+		{"\x1bOB", "down", true},
+		{"\x1bOP", "f1", true},
+		{"\x1b[1;5C", "ctrl+right", true},
+		{"\x1b[1;2A", "shift+up", true},
+		{"\x1b[1;8D", "ctrl+alt+shift+left", true},
+		{"\x1b[5~", "pgup", true},
+		{"\x1b[15~", "f5", true},
+		{"\x1b[15;2~", "shift+f5", true},
+		{"\x1b[Z", "shift+tab", true},
+		{"\x1b[99~", "", true},
+
+		// Mouse reports are ignored once complete
+		{"\x1b[M !", "", false},
+		{"\x1b[M !!", "", true},
+		{"\x1b[<0;10;5", "", false},
+		{"\x1b[<0;10;5M", "", true},
+	}
+	for _, tt := range tests {
+		got, done := parseKey([]byte(tt.seq))
+		if got != tt.want || done != tt.done {
+			t.Errorf("parseKey(%q) = %q, %v, want %q, %v", tt.seq, got, done, tt.want, tt.done)
+		}
+	}
+}
+
+func TestNormalizeKeyName(t *testing.T) {
+	tests := []struct {
+		name string
+		want keyEvent
+	}{
+		{"q", "q"},
+		{"Q", "Q"},
+		{"+", "+"},
+		{" ", "space"},
+		{"Space", "space"},
+		{"Ctrl+C", "ctrl+c"},
+		{"shift+ctrl+Up", "ctrl+shift+up"},
+		{"alt++", "alt++"},
+		{"F12", "f12"},
+	}
+	for _, tt := range tests {
+		got, err := normalizeKeyName(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("normalizeKeyName(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	for _, name := range []string{"hyper+x", "shift+a", "ctrl+1", "ctrl+m", "f13", "pagedown"} {
+		if got, err := normalizeKeyName(name); err == nil {
+			t.Errorf("normalizeKeyName(%q) = %q, want an error", name, got)
+		}
+	}
+}
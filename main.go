@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -18,12 +19,40 @@ var (
 	timerName    = flag.String("name", "", "name for the timer")
 	restoreMode  = flag.Bool("restore", false, "restore timer from sessions.json")
 	restoreModeS = flag.Bool("r", false, "restore timer from sessions.json (shorthand)")
+
+	httpAddr     = flag.String("http", "", "serve the HTTP API on this address (e.g. 127.0.0.1:8080)")
+	sequenceFile = flag.String("f", "", "run the steps of a sequence file (e.g. workout.tmr)")
+	repeatArg    = flag.String("repeat", "", "run the countdown N times, or forever with inf")
+	overtimeMode = flag.Bool("overtime", false, "keep counting past zero (shown as negative time) until q is pressed")
+	sinceArg     = flag.String("since", "", "count up from a past time (e.g. 09:12 or 2026-10-17T09:12)")
+	alertsArg    = flag.String("alerts", "", "alert at these remaining times or elapsed shares (e.g. 10m,5m,1m or 50%,90%)")
+
+	// Pomodoro mode
+	pomodoroMode   = flag.Bool("pomodoro", false, "run a pomodoro cycle of work and break phases")
+	workLength     = durationFlag("work", "pomodoro work phase length (default 25m)")
+	shortBreakLen  = durationFlag("short-break", "pomodoro short break length (default 5m)")
+	longBreakLen   = durationFlag("long-break", "pomodoro long break length (default 15m)")
+	longBreakEvery = flag.Int("long-break-every", 0, "take a long break after every N work phases (default 4)")
 )
 
 func usage() {
 	fmt.Fprintf(os.Stderr, "timer - minimal tui countdown/timer app under 5mb memory usage \n\n")
-	fmt.Fprintf(os.Stderr, "Usage: timer [options] [<duration>]\n\n")
-	fmt.Fprintf(os.Stderr, "Duration: number with unit (5s, 2m, 1h). No unit defaults to seconds.\n")
+	fmt.Fprintf(os.Stderr, "Usage: timer [options] [<duration>]\n")
+	fmt.Fprintf(os.Stderr, "       timer [options] <duration>:<label> [<duration>:<label> ...]\n")
+	fmt.Fprintf(os.Stderr, "       timer [options] -f <sequence file>\n")
+	fmt.Fprintf(os.Stderr, "       timer [options] pomodoro\n")
+	fmt.Fprintf(os.Stderr, "       timer [options] until [today|tomorrow|YYYY-MM-DD] HH:MM[:SS]\n")
+	fmt.Fprintf(os.Stderr, "       timer [options] since [today|yesterday|YYYY-MM-DD] HH:MM[:SS]\n")
+	fmt.Fprintf(os.Stderr, "       timer since list|clear <name>\n")
+	fmt.Fprintf(os.Stderr, "       timer [options] attach [<name>]\n")
+	fmt.Fprintf(os.Stderr, "       timer daemon [-detach]\n")
+	fmt.Fprintf(os.Stderr, "       timer ctl <command> [args]   (see 'timer ctl -h')\n")
+	fmt.Fprintf(os.Stderr, "       timer history path|compact\n")
+	fmt.Fprintf(os.Stderr, "       timer keys                   (print the key bindings)\n")
+	fmt.Fprintf(os.Stderr, "       timer stats [-by day|week|month|name] [-since DATE] [-until DATE] [-json]\n")
+	fmt.Fprintf(os.Stderr, "       timer heatmap [-name NAME] [-weeks N]\n\n")
+	fmt.Fprintf(os.Stderr, "Duration: number with unit (5s, 2m, 1.5h, 2d4h, \"90 min\") or clock notation (1:30, 01:30:00).\n")
+	fmt.Fprintf(os.Stderr, "          No unit defaults to seconds.\n")
 	fmt.Fprintf(os.Stderr, "          If omitted, runs as a counter (stopwatch) counting up from 00:00.\n\n")
 	fmt.Fprintf(os.Stderr, "Options:\n")
 	flag.PrintDefaults()
@@ -34,37 +63,85 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "  timer -i 30s             # inline mode countdown\n")
 	fmt.Fprintf(os.Stderr, "  timer -p 5m              # 5 minutes countdown starting paused\n")
 	fmt.Fprintf(os.Stderr, "  timer -name \"Pomodoro\" 25m  # named timer with notification\n")
+	fmt.Fprintf(os.Stderr, "  timer pomodoro           # 25m work / 5m break cycles, long break every 4\n")
+	fmt.Fprintf(os.Stderr, "  timer -work 50m -short-break 10m pomodoro\n")
+	fmt.Fprintf(os.Stderr, "  timer 25m:Write 5m:Break 25m:Review  # segments back to back, n skips\n")
+	fmt.Fprintf(os.Stderr, "  timer -f hiit.tmr                    # run a sequence file (repeat 8 { 20s Work; 10s Rest })\n")
+	fmt.Fprintf(os.Stderr, "  timer -repeat 8 45m              # stand up every 45 minutes, 8 times\n")
+	fmt.Fprintf(os.Stderr, "  timer -repeat inf 30m            # drink water every 30 minutes\n")
+	fmt.Fprintf(os.Stderr, "  timer until 17:30               # count down to the next 17:30\n")
+	fmt.Fprintf(os.Stderr, "  timer until 2026-12-31T23:59    # count down to a date, shown with days\n")
+	fmt.Fprintf(os.Stderr, "  timer since 09:12               # stopwatch started at 09:12\n")
+	fmt.Fprintf(os.Stderr, "  timer since -name outage 14:03  # stored since timer, reopen with 'timer since -name outage'\n")
+	fmt.Fprintf(os.Stderr, "  timer -overtime 30m             # show how far past zero the meeting ran\n")
+	fmt.Fprintf(os.Stderr, "  timer -alerts 10m,5m,1m 45m     # notify as the countdown passes each mark\n")
+	fmt.Fprintf(os.Stderr, "  timer -http 127.0.0.1:8080 25m  # serve the HTTP API while running\n")
+	fmt.Fprintf(os.Stderr, "  timer daemon -detach     # start the background daemon\n")
+	fmt.Fprintf(os.Stderr, "  timer ctl -name tea start 4m\n")
+	fmt.Fprintf(os.Stderr, "  timer attach tea         # view a daemon timer in this terminal\n")
 	fmt.Fprintf(os.Stderr, "  timer --restore          # restore timer from sessions.json\n")
 	fmt.Fprintf(os.Stderr, "  timer --restore -i       # restore in inline mode regardless of saved setting\n")
 }
 
+// exitOnError prints err and exits with a failure status if err is non-nil
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
 func main() {
 	flag.Usage = usage
+
+	// Subcommands with their own flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "daemon":
+			loadConfig()
+			exitOnError(runDaemon(os.Args[2:]))
+			return
+		case "ctl":
+			exitOnError(runCtl(os.Args[2:]))
+			return
+		case "stats":
+			loadConfig()
+			exitOnError(runStats(os.Args[2:]))
+			return
+		case "heatmap":
+			loadConfig()
+			exitOnError(runHeatmap(os.Args[2:]))
+			return
+		case "history":
+			loadConfig()
+			exitOnError(runHistory(os.Args[2:]))
+			return
+		case "keys":
+			loadConfig()
+			exitOnError(runKeys(os.Args[2:]))
+			return
+		}
+	}
+
 	flag.Parse()
 
 	// Load configuration from ~/.config/go-timer/config.json
 	loadConfig()
+	if keymapError != nil {
+		fmt.Fprintf(os.Stderr, "Warning: invalid \"keys\" in config, using the default keys: %v\n", keymapError)
+	}
 
-	// Get args after initial flag parse
-	args := flag.Args()
-
-	// Separate flags and positional from remaining args
+	// Parse flags interleaved with positional args (e.g. "timer 5m -i")
 	var positional []string
-	for _, arg := range args {
-		if strings.HasPrefix(arg, "-") {
-			if arg == "-i" || arg == "-inline" {
-				*inlineMode = true
-			} else if arg == "-p" || arg == "-paused" {
-				*pausedMode = true
-			} else if arg == "-v" || arg == "-version" {
-				*showVersion = true
-			} else {
-				usage()
-				os.Exit(1)
-			}
-		} else {
-			positional = append(positional, arg)
-		}
+	for args := flag.Args(); len(args) > 0; args = flag.Args() {
+		positional = append(positional, args[0])
+		flag.CommandLine.Parse(args[1:])
+	}
+
+	// "pomodoro" keyword is an alias for -pomodoro
+	if len(positional) > 0 && positional[0] == "pomodoro" {
+		*pomodoroMode = true
+		positional = positional[1:]
 	}
 
 	// Handle version
@@ -73,36 +150,176 @@ func main() {
 		return
 	}
 
-	// Accept 0 or 1 positional arg
-	if len(positional) > 1 {
+	// Attach to a daemon-owned timer as a pure view
+	if len(positional) > 0 && positional[0] == "attach" {
+		if len(positional) > 2 {
+			usage()
+			os.Exit(1)
+		}
+		name := *timerName
+		if len(positional) == 2 {
+			name = positional[1]
+		}
+		exitOnError(runAttached(name, !(*inlineMode || *inlineModeS)))
+		return
+	}
+
+	// Count down to a wall-clock time, e.g. "timer until 17:30"
+	var untilTarget time.Time
+	if len(positional) > 0 && positional[0] == "until" {
+		if *pomodoroMode || *sequenceFile != "" || *repeatArg != "" {
+			exitOnError(fmt.Errorf("until cannot be combined with pomodoro mode, -f or -repeat"))
+		}
+		target, err := parseUntil(positional[1:], time.Now())
+		exitOnError(err)
+		untilTarget = target
+		positional = nil
+	}
+
+	// Count up from a past time, e.g. "timer since 09:12" or -since. Named
+	// since timers are stored and resumed by name.
+	var since time.Time
+	if (len(positional) > 0 && positional[0] == "since") || *sinceArg != "" {
+		var sinceArgs []string
+		if *sinceArg != "" {
+			if len(positional) > 0 {
+				exitOnError(fmt.Errorf("-since cannot be combined with a duration"))
+			}
+			sinceArgs = strings.Fields(*sinceArg)
+		} else {
+			sinceArgs = positional[1:]
+		}
+		if len(sinceArgs) > 0 && (sinceArgs[0] == "list" || sinceArgs[0] == "clear") {
+			exitOnError(runSinceCommand(sinceArgs))
+			return
+		}
+		if *pomodoroMode || *sequenceFile != "" || *repeatArg != "" || !untilTarget.IsZero() ||
+			*overtimeMode || *restoreMode || *restoreModeS {
+			exitOnError(fmt.Errorf("since cannot be combined with pomodoro mode, -f, -repeat, until, -overtime or -restore"))
+		}
+		start, err := sinceStart(sinceArgs, *timerName, time.Now())
+		exitOnError(err)
+		since = start
+		positional = nil
+	}
+
+	// No positional args in pomodoro mode
+	if *pomodoroMode && len(positional) > 0 {
 		usage()
 		os.Exit(1)
 	}
 
-	// Parse duration (0 means counter mode)
+	// Parse duration (0 means counter mode). Several arguments, or one with
+	// a label, are chained segments like "25m:Write 5m:Break".
 	var duration time.Duration
-	if len(positional) == 0 {
+	var segments []segment
+	args := joinUnitWords(positional)
+	single := len(args) == 1
+	if single {
+		_, label := splitSegmentLabel(args[0])
+		single = label == ""
+	}
+	switch {
+	case len(args) == 0:
 		// Counter mode - use 0 duration as signal
 		duration = 0
-	} else {
-		durStr := positional[0]
-		addSuffixIfArgIsNumber(&durStr, "s")
+	case single:
+		// A single duration, where 0 also means counter mode
 		var err error
-		duration, err = time.ParseDuration(durStr)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: invalid duration %q\n", positional[0])
-			os.Exit(1)
+		duration, err = parseDurationArg(args[0])
+		exitOnError(err)
+		if duration < 0 {
+			exitOnError(fmt.Errorf("invalid duration %q: must not be negative", args[0]))
 		}
+	default:
+		for _, arg := range args {
+			seg, err := parseSegmentArg(arg)
+			exitOnError(err)
+			segments = append(segments, seg)
+		}
+		duration = segments[0].duration
+	}
+	if !untilTarget.IsZero() {
+		duration = time.Until(untilTarget)
+	}
+
+	// Build the phase plan: a pomodoro cycle from flags (falling back to
+	// config values), chained segments, a sequence file or a repeated
+	// countdown
+	var plan phasePlan
+	if segments != nil {
+		chain, err := newSegmentPlan(segments)
+		exitOnError(err)
+		plan = chain
+	}
+	if *sequenceFile != "" {
+		if len(positional) > 0 || *pomodoroMode {
+			exitOnError(fmt.Errorf("-f cannot be combined with durations or pomodoro mode"))
+		}
+		sequence, err := loadSequence(*sequenceFile)
+		exitOnError(err)
+		plan = sequence
+		_, duration = plan.phase()
+		if *timerName == "" {
+			*timerName = strings.TrimSuffix(sequence.source, filepath.Ext(sequence.source))
+		}
+	}
+	if *pomodoroMode {
+		work, shortBreak, longBreak, every := pomodoroWork, pomodoroShortBreak, pomodoroLongBreak, pomodoroLongBreakEvery
+		if *workLength != 0 {
+			work = *workLength
+		}
+		if *shortBreakLen != 0 {
+			shortBreak = *shortBreakLen
+		}
+		if *longBreakLen != 0 {
+			longBreak = *longBreakLen
+		}
+		if *longBreakEvery != 0 {
+			every = *longBreakEvery
+		}
+		pomodoro, err := newPomodoroPlan(work, shortBreak, longBreak, every)
+		exitOnError(err)
+		plan = pomodoro
+		_, duration = plan.phase()
+	}
+	if *repeatArg != "" {
+		if plan != nil {
+			exitOnError(fmt.Errorf("-repeat cannot be combined with pomodoro mode, segments or -f"))
+		}
+		count, err := parseRepeatCount(*repeatArg)
+		exitOnError(err)
+		repeat, err := newRepeatPlan(duration, count)
+		exitOnError(err)
+		plan = repeat
+	}
+
+	if *overtimeMode && plan != nil {
+		exitOnError(fmt.Errorf("-overtime cannot be combined with pomodoro, repeat, segments or -f"))
+	}
+	overtime := *overtimeMode
+
+	// Intermediate alerts from the flag, falling back to config
+	alerts := defaultAlerts
+	if *alertsArg != "" {
+		var err error
+		alerts, err = parseAlerts(*alertsArg)
+		exitOnError(err)
 	}
 
 	// Handle restore mode (manual or auto)
 	isRestore := *restoreMode || *restoreModeS
-	if duration == 0 && restoreEnabled && !isRestore {
+	if duration == 0 && since.IsZero() && restoreEnabled && !isRestore {
 		// Auto-restore if no duration specified and config has restore=true
 		isRestore = true
 	}
 	var restoredSession Session
 	var initialElapsed time.Duration
+	var phases []PhaseRecord
+	var laps []LapRecord
+	var alerted []string
+	var adjustments []AdjustmentRecord
+	var restarts []RestartRecord
 	if isRestore {
 		var err error
 		restoredSession, err = loadSession()
@@ -113,17 +330,57 @@ func main() {
 		// Override parameters from session
 		if restoredSession.Mode == "counter" {
 			duration = 0
+			initialElapsed = parseFormattedDuration(restoredSession.Elapsed)
+			laps = restoredSession.Laps
+			if restoredSession.Since != "" {
+				// A since timer kept counting while no timer was running
+				since, _ = time.Parse(time.RFC3339, restoredSession.Since)
+				current, err := time.ParseInLocation(sessionTimeLayout, restoredSession.Current, time.Local)
+				if err == nil && !restoredSession.Paused {
+					initialElapsed += time.Since(current)
+				}
+			}
 		} else {
 			elapsed := parseFormattedDuration(restoredSession.Elapsed)
 			remaining := parseFormattedDuration(restoredSession.Remaining)
 			duration = elapsed + remaining
 			initialElapsed = elapsed
+			alerted = restoredSession.Alerts
+			if restoredSession.Overtime != "" {
+				overtime = true
+				duration -= parseFormattedDuration(restoredSession.Overtime)
+			}
+			if restoredSession.Until != "" {
+				// An until timer still ends at its target; a paused one
+				// keeps its remaining time, moving the target on resume
+				untilTarget, err = time.Parse(time.RFC3339, restoredSession.Until)
+				exitOnError(err)
+				if restoredSession.Paused {
+					untilTarget = time.Now().Add(duration - elapsed)
+				} else {
+					duration = max(elapsed+time.Until(untilTarget), time.Nanosecond)
+				}
+			}
+		}
+		plan, err = planFromSession(restoredSession)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if plan != nil {
+			phases = restoredSession.Phases
 		}
+		adjustments = restoredSession.Adjustments
+		restarts = restoredSession.Restarts
 		if *timerName == "" {
 			*timerName = restoredSession.Name
 		}
 	}
 
+	if !since.IsZero() && !isRestore {
+		initialElapsed = time.Since(since)
+	}
+
 	// Merge short/long flags - fullscreen is default, inline disables it
 	userProvidedInline := *inlineMode || *inlineModeS
 	userProvidedPaused := *pausedMode || *pausedModeS
@@ -144,13 +401,36 @@ func main() {
 	summaryCh := make(chan TimerSummary, 1)
 
 	// Run timer (fullscreen unless inline flag is set)
-	if err := runTimer(duration, !useInline, initialPaused, *timerName, initialElapsed, summaryCh); err != nil {
+	opts := timerOptions{
+		duration:       duration,
+		fullscreen:     !useInline,
+		paused:         initialPaused,
+		name:           *timerName,
+		initialElapsed: initialElapsed,
+		since:          since,
+		until:          untilTarget,
+		overtime:       overtime,
+		plan:           plan,
+		phases:         phases,
+		laps:           laps,
+		alerts:         alerts,
+		alerted:        alerted,
+		adjustments:    adjustments,
+		restarts:       restarts,
+		httpAddr:       *httpAddr,
+	}
+	if err := runTimer(opts, summaryCh); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Receive and print summary
+	// Receive summary and record the run in the history log
 	summary := <-summaryCh
+	if err := appendHistory(summary); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record history: %v\n", err)
+	}
+
+	// Print summary
 	if summary.Name != "" {
 		fmt.Printf("Name: %s\n", summary.Name)
 	}
@@ -158,5 +438,54 @@ func main() {
 	fmt.Printf("End: %s\n", summary.End.Format("2006-01-02 15:04:05"))
 	fmt.Printf("Duration: %s\n", summary.Duration)
 	fmt.Printf("Mode: %s\n", summary.Mode)
-	fmt.Printf("Finished: %t\n", summary.Finished)
+	if summary.Early {
+		fmt.Printf("Finished: %t (early)\n", summary.Finished)
+	} else {
+		fmt.Printf("Finished: %t\n", summary.Finished)
+	}
+	if summary.Overtime > 0 {
+		fmt.Printf("Overtime: %s\n", summary.Overtime)
+	}
+	if len(summary.Phases) > 0 {
+		fmt.Printf("Phases:\n")
+		fmt.Printf("  %-4s %-16s %-9s %s\n", "#", "Phase", "Planned", "Actual")
+		for _, p := range summary.Phases {
+			actual := formatHMS(parseFormattedDuration(p.Elapsed))
+			if p.Skipped {
+				actual += " (skipped)"
+			}
+			fmt.Printf("  %-4d %-16s %-9s %s\n", p.Cycle, p.Phase,
+				formatHMS(parseFormattedDuration(p.Planned)), actual)
+		}
+	}
+	if len(summary.Laps) > 0 {
+		fmt.Printf("Laps:\n")
+		fmt.Printf("  %-4s %-12s %s\n", "#", "Lap", "Split")
+		for _, l := range summary.Laps {
+			fmt.Printf("  %-4d %-12s %s\n", l.Number,
+				formatLapTime(parseFormattedDuration(l.Lap)),
+				formatLapTime(parseFormattedDuration(l.Split)))
+		}
+	}
+	if len(summary.Adjustments) > 0 {
+		fmt.Printf("Adjustments: %s net\n", formatAdjustment(netAdjustment(summary.Adjustments)))
+		fmt.Printf("  %-12s %s\n", "Elapsed", "Change")
+		for _, a := range summary.Adjustments {
+			fmt.Printf("  %-12s %s\n", formatHMS(parseFormattedDuration(a.Elapsed)),
+				formatAdjustment(parseFormattedDuration(a.Delta)))
+		}
+	}
+	if len(summary.Restarts) > 0 {
+		fmt.Printf("Restarts:\n")
+		fmt.Printf("  %-12s %s\n", "Discarded", "Action")
+		for _, r := range summary.Restarts {
+			fmt.Printf("  %-12s %s\n", formatHMS(parseFormattedDuration(r.Elapsed)), r.Action)
+		}
+	}
+	if len(summary.NotifyFailures) > 0 {
+		fmt.Printf("Notification failures:\n")
+		for _, failure := range summary.NotifyFailures {
+			fmt.Printf("  %s\n", failure)
+		}
+	}
 }
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Run outcomes reported by the timer_runs_total counter, keyed by the
+// lifecycle event that ended the run
+var metricsOutcomes = map[string]string{
+	"finish":    "completed",
+	"quit":      "quit",
+	"interrupt": "interrupted",
+}
+
+// timerMetrics counts ended runs for the Prometheus text exposition served
+// at /metrics. It is written by hand to keep client libraries out of the
+// binary.
+type timerMetrics struct {
+	mu      sync.Mutex
+	runs    map[[2]string]int        // name, outcome
+	phases  map[[2]string]int        // name, outcome of ended plan phases
+	focused map[string]time.Duration // effective run time per name
+}
+
+func newTimerMetrics() *timerMetrics {
+	return &timerMetrics{
+		runs:    make(map[[2]string]int),
+		phases:  make(map[[2]string]int),
+		focused: make(map[string]time.Duration),
+	}
+}
+
+// runEnded accounts a run ended by event (finish, quit or interrupt). Only
+// the daemon calls it: a foreground timer's listener closes with its run.
+func (m *timerMetrics) runEnded(name, event string, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[[2]string{name, metricsOutcomes[event]}]++
+	m.focused[name] += elapsed
+}
+
+// phaseEnded accounts a phase of a multi-phase run that ended while the
+// run goes on, so that such runs report progress before they exit
+func (m *timerMetrics) phaseEnded(name string, skipped bool, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcome := "completed"
+	if skipped {
+		outcome = "skipped"
+	}
+	m.phases[[2]string{name, outcome}]++
+	m.focused[name] += elapsed
+}
+
+// sortedKeys returns the keys of a name, outcome counter in order
+func sortedKeys(counts map[[2]string]int) [][2]string {
+	keys := make([][2]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
+
+// escapeLabel escapes a label value for the text exposition format
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// write renders gauges for the given timers and the run counters
+func (m *timerMetrics) write(w io.Writer, sessions []Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP timer_elapsed_seconds Effective run time of the timer, excluding pauses.")
+	fmt.Fprintln(w, "# TYPE timer_elapsed_seconds gauge")
+	for _, s := range sessions {
+		fmt.Fprintf(w, "timer_elapsed_seconds{name=\"%s\"} %g\n",
+			escapeLabel(s.Name), parseFormattedDuration(s.Elapsed).Seconds())
+	}
+
+	fmt.Fprintln(w, "# HELP timer_remaining_seconds Time left on the countdown.")
+	fmt.Fprintln(w, "# TYPE timer_remaining_seconds gauge")
+	for _, s := range sessions {
+		if s.Remaining == "" {
+			continue
+		}
+		fmt.Fprintf(w, "timer_remaining_seconds{name=\"%s\"} %g\n",
+			escapeLabel(s.Name), parseFormattedDuration(s.Remaining).Seconds())
+	}
+
+	fmt.Fprintln(w, "# HELP timer_paused Whether the timer is paused (1) or running (0).")
+	fmt.Fprintln(w, "# TYPE timer_paused gauge")
+	for _, s := range sessions {
+		paused := 0
+		if s.Paused {
+			paused = 1
+		}
+		fmt.Fprintf(w, "timer_paused{name=\"%s\"} %d\n", escapeLabel(s.Name), paused)
+	}
+
+	fmt.Fprintln(w, "# HELP timer_mode Mode of the timer (timer, counter, pomodoro, repeat, segments or sequence), always 1.")
+	fmt.Fprintln(w, "# TYPE timer_mode gauge")
+	for _, s := range sessions {
+		fmt.Fprintf(w, "timer_mode{name=\"%s\",mode=\"%s\"} 1\n", escapeLabel(s.Name), s.Mode)
+	}
+
+	fmt.Fprintln(w, "# HELP timer_runs_total Ended daemon runs by outcome (completed, quit or interrupted).")
+	fmt.Fprintln(w, "# TYPE timer_runs_total counter")
+	for _, key := range sortedKeys(m.runs) {
+		fmt.Fprintf(w, "timer_runs_total{name=\"%s\",outcome=\"%s\"} %d\n",
+			escapeLabel(key[0]), key[1], m.runs[key])
+	}
+
+	fmt.Fprintln(w, "# HELP timer_phases_total Ended phases of multi-phase runs by outcome (completed or skipped), except the last.")
+	fmt.Fprintln(w, "# TYPE timer_phases_total counter")
+	for _, key := range sortedKeys(m.phases) {
+		fmt.Fprintf(w, "timer_phases_total{name=\"%s\",outcome=\"%s\"} %d\n",
+			escapeLabel(key[0]), key[1], m.phases[key])
+	}
+
+	names := make([]string, 0, len(m.focused))
+	for name := range m.focused {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "# HELP timer_focused_seconds_total Effective run time of ended runs and phases.")
+	fmt.Fprintln(w, "# TYPE timer_focused_seconds_total counter")
+	for _, name := range names {
+		fmt.Fprintf(w, "timer_focused_seconds_total{name=\"%s\"} %g\n",
+			escapeLabel(name), m.focused[name].Seconds())
+	}
+}
+
+// metricsHandler serves the metrics of the timers returned by sessions
+func metricsHandler(m *timerMetrics, sessions func() []Session) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.write(w, sessions())
+	}
+}
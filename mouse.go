@@ -0,0 +1,175 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// mouseEvent is a decoded mouse press or wheel step at a 1-based cell
+type mouseEvent struct {
+	button string // "left", "middle", "right", "wheel-up" or "wheel-down"
+	x, y   int
+}
+
+// Buttons by the low bits of a mouse report, with 64 added for the wheel
+var mouseButtons = map[int]string{0: "left", 1: "middle", 2: "right", 64: "wheel-up", 65: "wheel-down"}
+
+// parseMouse decodes a complete mouse report, in X10 (\033[M b x y) or SGR
+// (\033[<b;x;yM) encoding. Releases and motion are reported as not ok.
+func parseMouse(seq []byte) (mouseEvent, bool) {
+	var b, x, y int
+	switch {
+	case len(seq) == 6 && string(seq[:3]) == "\033[M":
+		// X10: values offset by 32; button 3 is a release
+		b, x, y = int(seq[3])-32, int(seq[4])-32, int(seq[5])-32
+		if b&3 == 3 && b&64 == 0 {
+			return mouseEvent{}, false
+		}
+	case len(seq) > 3 && string(seq[:3]) == "\033[<":
+		last := seq[len(seq)-1]
+		fields := strings.Split(string(seq[3:len(seq)-1]), ";")
+		if last != 'M' || len(fields) != 3 {
+			return mouseEvent{}, false // Release or malformed
+		}
+		var err error
+		values := make([]int, 3)
+		for i, field := range fields {
+			if values[i], err = strconv.Atoi(field); err != nil {
+				return mouseEvent{}, false
+			}
+		}
+		b, x, y = values[0], values[1], values[2]
+	default:
+		return mouseEvent{}, false
+	}
+	if b&32 != 0 {
+		return mouseEvent{}, false // Motion
+	}
+	button, ok := mouseButtons[b&(64|3)]
+	return mouseEvent{button: button, x: x, y: y}, ok
+}
+
+// screenButton is a clickable label drawn under the big time
+type screenButton struct {
+	label  string
+	action string // key action run when clicked
+}
+
+// rect is an area of the screen in 1-based cells
+type rect struct {
+	row, col, width, height int
+}
+
+func (r rect) contains(x, y int) bool {
+	return x >= r.col && x < r.col+r.width && y >= r.row && y < r.row+r.height
+}
+
+// frameLayout places the lines of a fullscreen frame on a screen of the
+// given size, keeping where the time and the buttons were drawn for
+// hit-testing mouse clicks
+type frameLayout struct {
+	width, height int
+	lines         []string
+	time          rect
+	buttons       []rect // areas of frame.buttons
+}
+
+// centerOffset returns the offset centering length cells in size
+func centerOffset(size, length int) int {
+	return max((size-length)/2, 0)
+}
+
+// buttonGap separates the buttons drawn under the time
+const buttonGap = "   "
+
+// buttonText draws a button label, e.g. "[ Pause ]"
+func buttonText(label string) string {
+	return "[ " + label + " ]"
+}
+
+// layoutFrame lays out a frame centered on a width x height screen
+func layoutFrame(f frame, width, height int) frameLayout {
+	l := frameLayout{width: width, height: height}
+	if f.header != "" {
+		l.lines = append(l.lines, f.header, "")
+	}
+	timeStart := len(l.lines)
+	l.lines = append(l.lines, strings.Split(renderBigTime(f.timeStr, width, height), "\n")...)
+	timeLines := l.lines[timeStart:]
+
+	buttonsLine := -1
+	var labels []string
+	for _, b := range f.buttons {
+		labels = append(labels, buttonText(b.label))
+	}
+	// Buttons are left out when the screen has no room for them
+	footerLines := 0
+	if len(f.footer) > 0 {
+		footerLines = len(f.footer) + 1
+	}
+	if len(labels) > 0 && height >= len(l.lines)+2+footerLines {
+		l.lines = append(l.lines, "", strings.Join(labels, buttonGap))
+		buttonsLine = len(l.lines) - 1
+	}
+	if len(f.footer) > 0 {
+		l.lines = append(l.lines, "")
+		l.lines = append(l.lines, f.footer...)
+	}
+
+	top := centerOffset(height, len(l.lines)) + 1
+	timeWidth := len([]rune(timeLines[0]))
+	l.time = rect{
+		row:    top + timeStart,
+		col:    centerOffset(width, timeWidth) + 1,
+		width:  timeWidth,
+		height: len(timeLines),
+	}
+	if buttonsLine >= 0 {
+		col := centerOffset(width, len([]rune(l.lines[buttonsLine]))) + 1
+		for _, label := range labels {
+			n := len([]rune(label))
+			l.buttons = append(l.buttons, rect{row: top + buttonsLine, col: col, width: n, height: 1})
+			col += n + len(buttonGap)
+		}
+	}
+	return l
+}
+
+// mouseAction returns the key action of a mouse event on a frame: clicking
+// the time pauses or resumes, the wheel adds or removes time, and clicking
+// a button runs its action
+func mouseAction(m mouseEvent, f frame, l frameLayout) string {
+	switch m.button {
+	case "wheel-up":
+		return "add-time"
+	case "wheel-down":
+		return "remove-time"
+	case "left":
+		if l.time.contains(m.x, m.y) {
+			return "pause"
+		}
+		for i, r := range l.buttons {
+			if r.contains(m.x, m.y) {
+				return f.buttons[i].action
+			}
+		}
+	}
+	return ""
+}
+
+// frameButtons returns the buttons drawn under the time. Since and until
+// timers cannot be reset, so they have no Reset button.
+func frameButtons(st *timerState) []screenButton {
+	pause := screenButton{"Pause", "pause"}
+	if st.paused {
+		pause.label = "Resume"
+	}
+	buttons := []screenButton{pause}
+	if st.since.IsZero() && st.until.IsZero() {
+		buttons = append(buttons, screenButton{"Reset", "reset"})
+	}
+	return append(buttons,
+		screenButton{"+" + shortDuration(adjustStep), "add-time"},
+		screenButton{"Quit", "quit"},
+	)
+}
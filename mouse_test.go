@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestParseMouse(t *testing.T) {
+	tests := []struct {
+		seq  string
+		want mouseEvent
+		ok   bool
+	}{
+		// SGR
+		{"\x1b[<0;10;5M", mouseEvent{"left", 10, 5}, true},
+		{"\x1b[<1;1;1M", mouseEvent{"middle", 1, 1}, true},
+		{"\x1b[<2;300;80M", mouseEvent{"right", 300, 80}, true},
+		{"\x1b[<64;3;4M", mouseEvent{"wheel-up", 3, 4}, true},
+		{"\x1b[<65;3;4M", mouseEvent{"wheel-down", 3, 4}, true},
+		{"\x1b[<16;10;5M", mouseEvent{"left", 10, 5}, true}, // Ctrl+click
+		{"\x1b[<0;10;5m", mouseEvent{}, false},              // Release
+		{"\x1b[<32;10;5M", mouseEvent{}, false},             // Drag
+		{"\x1b[<35;10;5M", mouseEvent{}, false},             // Motion
+		{"\x1b[<0;10M", mouseEvent{}, false},
+		{"\x1b[<0;x;5M", mouseEvent{}, false},
+
+		// X10, offset by 32
+		{"\x1b[M *%", mouseEvent{"left", 10, 5}, true},
+		{"\x1b[M\"!!", mouseEvent{"right", 1, 1}, true},
+		{"\x1b[M`!!", mouseEvent{"wheel-up", 1, 1}, true},
+		{"\x1b[M#*%", mouseEvent{}, false}, // Release
+
+		// Not mouse reports
+		{"\x1b[A", mouseEvent{}, false},
+		{"q", mouseEvent{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseMouse([]byte(tt.seq))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseMouse(%q) = %+v, %v, want %+v, %v", tt.seq, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// Maximum run time of notify-send and custom notification commands
+const notifyTimeout = 5 * time.Second
+
+// notifier is a notification backend
+type notifier interface {
+	name() string
+	send(title, message string) error
+}
+
+// NotifierConfig is one entry of the "notifiers" chain in config.json
+type NotifierConfig struct {
+	Type       string `json:"type"`                 // notify-send, bell, osc9, osc777, command or log
+	Urgency    string `json:"urgency,omitempty"`    // notify-send: low, normal or critical
+	Icon       string `json:"icon,omitempty"`       // notify-send: icon name or path
+	ExpireTime int    `json:"expireTime,omitempty"` // notify-send: milliseconds
+	Command    string `json:"command,omitempty"`    // command: shell command
+	Path       string `json:"path,omitempty"`       // log: file to append to
+	Continue   bool   `json:"continue,omitempty"`   // also run the next backend after a success
+}
+
+// notifierLink is a backend in the notification chain
+type notifierLink struct {
+	notifier
+	next bool // run the next backend even when this one succeeds
+}
+
+// newNotifier builds the backend described by a config entry
+func newNotifier(c NotifierConfig) (notifier, error) {
+	switch c.Type {
+	case "notify-send":
+		switch c.Urgency {
+		case "", "low", "normal", "critical":
+		default:
+			return nil, fmt.Errorf("invalid urgency %q", c.Urgency)
+		}
+		if c.ExpireTime < 0 {
+			return nil, fmt.Errorf("invalid expire time %d", c.ExpireTime)
+		}
+		return notifySend{urgency: c.Urgency, icon: c.Icon, expireTime: c.ExpireTime}, nil
+	case "bell":
+		return bellNotifier{}, nil
+	case "osc9", "osc777":
+		return oscNotifier{osc777: c.Type == "osc777"}, nil
+	case "command":
+		if c.Command == "" {
+			return nil, fmt.Errorf("command notifier needs a command")
+		}
+		return commandNotifier{command: c.Command}, nil
+	case "log":
+		if c.Path == "" {
+			return nil, fmt.Errorf("log notifier needs a path")
+		}
+		return logNotifier{path: c.Path}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", c.Type)
+	}
+}
+
+// defaultNotifyChain returns the chain used without a "notifiers" config:
+// desktop notifications falling back to the bell on Linux, where
+// notify-send is available, and the bell elsewhere
+func defaultNotifyChain() []notifierLink {
+	if runtime.GOOS == "linux" {
+		return []notifierLink{{notifier: notifySend{}}, {notifier: bellNotifier{}}}
+	}
+	return []notifierLink{{notifier: bellNotifier{}}}
+}
+
+// chainHasBell reports whether the notification chain can ring the bell
+func chainHasBell() bool {
+	return slices.ContainsFunc(notifyChain, func(link notifierLink) bool {
+		_, ok := link.notifier.(bellNotifier)
+		return ok
+	})
+}
+
+// notify sends a notification through the chain: backends are tried in
+// order until one succeeds, so a failing backend falls through to the next.
+// It returns the failures of the backends that were tried.
+func notify(title, message string) []error {
+	var failures []error
+	for _, link := range notifyChain {
+		if err := link.send(title, message); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", link.name(), err))
+			continue
+		}
+		if !link.next {
+			break
+		}
+	}
+	return failures
+}
+
+// notifySend shows a desktop notification with notify-send
+type notifySend struct {
+	urgency    string
+	icon       string
+	expireTime int
+}
+
+func (n notifySend) name() string { return "notify-send" }
+
+func (n notifySend) send(title, message string) error {
+	var args []string
+	if n.urgency != "" {
+		args = append(args, "--urgency="+n.urgency)
+	}
+	if n.icon != "" {
+		args = append(args, "--icon="+n.icon)
+	}
+	if n.expireTime > 0 {
+		args = append(args, "--expire-time="+strconv.Itoa(n.expireTime))
+	}
+	args = append(args, title, message)
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, "notify-send", args...).CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// writeTerminal writes a control sequence to stdout, which must be a terminal
+func writeTerminal(seq string) error {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("stdout is not a terminal")
+	}
+	_, err := os.Stdout.WriteString(seq)
+	return err
+}
+
+// stripControl removes control characters that would end an escape sequence
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// bellNotifier rings the terminal bell
+type bellNotifier struct{}
+
+func (bellNotifier) name() string { return "bell" }
+
+func (bellNotifier) send(title, message string) error {
+	return writeTerminal("\a")
+}
+
+// oscNotifier asks the terminal emulator to show a notification with
+// OSC 9 (iTerm2, Windows Terminal, ...) or OSC 777 (rxvt, foot, ...).
+// Terminals without support silently ignore the sequence.
+type oscNotifier struct {
+	osc777 bool
+}
+
+func (n oscNotifier) name() string {
+	if n.osc777 {
+		return "osc777"
+	}
+	return "osc9"
+}
+
+func (n oscNotifier) send(title, message string) error {
+	title, message = stripControl(title), stripControl(message)
+	if n.osc777 {
+		return writeTerminal(fmt.Sprintf("\033]777;notify;%s;%s\a", title, message))
+	}
+	return writeTerminal(fmt.Sprintf("\033]9;%s: %s\a", title, message))
+}
+
+// commandNotifier runs a shell command with the notification in
+// TIMER_NOTIFY_TITLE and TIMER_NOTIFY_MESSAGE
+type commandNotifier struct {
+	command string
+}
+
+func (commandNotifier) name() string { return "command" }
+
+func (n commandNotifier) send(title, message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", n.command)
+	cmd.Env = append(os.Environ(),
+		"TIMER_NOTIFY_TITLE="+title,
+		"TIMER_NOTIFY_MESSAGE="+message,
+	)
+	return cmd.Run()
+}
+
+// logNotifier appends notifications to a file
+type logNotifier struct {
+	path string
+}
+
+func (logNotifier) name() string { return "log" }
+
+func (n logNotifier) send(title, message string) error {
+	path := n.path
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(home, rest)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s %s: %s\n", time.Now().Format(time.RFC3339), title, message); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// notifications sends the notifications of one run and collects their
+// failures for the summary
+type notifications struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	failures []string
+}
+
+func (n *notifications) send(title, message string) {
+	failures := notify(title, message)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, err := range failures {
+		n.failures = append(n.failures, err.Error())
+	}
+}
+
+// sendAsync sends a notification without blocking the caller
+func (n *notifications) sendAsync(title, message string) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.send(title, message)
+	}()
+}
+
+// wait blocks until pending notifications are sent and returns the failures
+func (n *notifications) wait() []string {
+	n.wg.Wait()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.failures
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// paletteCommands lists the commands of the ':' prompt, in completion order
+var paletteCommands = []string{"set", "add", "name", "reset", "restart", "finish", "overtime"}
+
+// paletteHistorySize is how many entered commands the prompt remembers
+const paletteHistorySize = 50
+
+// parsedCommand is a validated prompt line
+type parsedCommand struct {
+	name     string
+	duration time.Duration // set and add
+	text     string        // name
+	on       bool          // overtime
+}
+
+// parseCommand parses and validates a prompt line such as "add 2m"
+func parseCommand(line string) (parsedCommand, error) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+	cmd := parsedCommand{name: name}
+	switch name {
+	case "set", "add":
+		if arg == "" {
+			return cmd, fmt.Errorf("usage: %s DURATION", name)
+		}
+		d, err := parseDurationArg(arg)
+		if err != nil {
+			return cmd, err
+		}
+		if name == "set" && d <= 0 {
+			return cmd, fmt.Errorf("set needs a positive duration")
+		}
+		cmd.duration = d
+	case "name":
+		cmd.text = arg
+	case "reset", "restart", "finish":
+		if arg != "" {
+			return cmd, fmt.Errorf("%s takes no argument", name)
+		}
+	case "overtime":
+		switch arg {
+		case "on":
+			cmd.on = true
+		case "off":
+		default:
+			return cmd, fmt.Errorf("usage: overtime on|off")
+		}
+	case "":
+		return cmd, fmt.Errorf("empty command")
+	default:
+		return cmd, fmt.Errorf("unknown command %q (try tab)", name)
+	}
+	return cmd, nil
+}
+
+// palette is the ':' command prompt with its line editor. While open it
+// takes all keys; after a command runs its result stays on the prompt
+// line until the next key.
+type palette struct {
+	open    bool
+	text    []rune
+	cursor  int
+	message string // result of the last command, or completion candidates
+
+	history []string
+	histPos int    // position while browsing history, len(history) when not
+	draft   string // line being typed before browsing history
+}
+
+// start opens an empty prompt
+func (p *palette) start() {
+	p.open = true
+	p.text, p.cursor, p.message = nil, 0, ""
+	p.histPos = len(p.history)
+}
+
+// key edits the prompt line. It returns the entered line and true when
+// enter was pressed; esc or ctrl+c closes the prompt without a command.
+func (p *palette) key(key keyEvent) (string, bool) {
+	p.message = ""
+	switch key {
+	case "enter":
+		line := strings.TrimSpace(string(p.text))
+		p.open = false
+		if line != "" && (len(p.history) == 0 || p.history[len(p.history)-1] != line) {
+			p.history = append(p.history, line)
+			if len(p.history) > paletteHistorySize {
+				p.history = p.history[1:]
+			}
+		}
+		return line, line != ""
+	case "esc", "ctrl+c", "ctrl+g":
+		p.open = false
+	case "left", "ctrl+b":
+		p.cursor = max(p.cursor-1, 0)
+	case "right", "ctrl+f":
+		p.cursor = min(p.cursor+1, len(p.text))
+	case "home", "ctrl+a":
+		p.cursor = 0
+	case "end", "ctrl+e":
+		p.cursor = len(p.text)
+	case "backspace":
+		if p.cursor > 0 {
+			p.text = append(p.text[:p.cursor-1], p.text[p.cursor:]...)
+			p.cursor--
+		} else if len(p.text) == 0 {
+			p.open = false // Backspace on an empty prompt closes it, as in vim
+		}
+	case "delete", "ctrl+d":
+		if p.cursor < len(p.text) {
+			p.text = append(p.text[:p.cursor], p.text[p.cursor+1:]...)
+		}
+	case "ctrl+u": // Delete to the start of the line
+		p.text = p.text[p.cursor:]
+		p.cursor = 0
+	case "ctrl+k": // Delete to the end of the line
+		p.text = p.text[:p.cursor]
+	case "ctrl+w": // Delete the word before the cursor
+		start := p.cursor
+		for start > 0 && unicode.IsSpace(p.text[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(p.text[start-1]) {
+			start--
+		}
+		p.text = append(p.text[:start], p.text[p.cursor:]...)
+		p.cursor = start
+	case "up", "ctrl+p":
+		if p.histPos > 0 {
+			if p.histPos == len(p.history) {
+				p.draft = string(p.text)
+			}
+			p.histPos--
+			p.setText(p.history[p.histPos])
+		}
+	case "down", "ctrl+n":
+		if p.histPos < len(p.history) {
+			p.histPos++
+			if p.histPos == len(p.history) {
+				p.setText(p.draft)
+			} else {
+				p.setText(p.history[p.histPos])
+			}
+		}
+	case "tab":
+		p.complete()
+	case "space":
+		p.insert(' ')
+	default:
+		// Printable characters are named by themselves
+		if r, size := utf8.DecodeRuneInString(string(key)); size == len(key) && unicode.IsPrint(r) {
+			p.insert(r)
+		}
+	}
+	return "", false
+}
+
+func (p *palette) insert(r rune) {
+	p.text = append(p.text[:p.cursor], append([]rune{r}, p.text[p.cursor:]...)...)
+	p.cursor++
+}
+
+func (p *palette) setText(s string) {
+	p.text = []rune(s)
+	p.cursor = len(p.text)
+}
+
+// complete completes the command name, or the argument of overtime. With
+// several candidates it completes their common prefix and lists them.
+func (p *palette) complete() {
+	line := string(p.text[:p.cursor])
+	var candidates []string
+	var word string
+	if name, arg, found := strings.Cut(line, " "); !found {
+		word = name
+		candidates = paletteCommands
+	} else if name == "overtime" && !strings.Contains(arg, " ") {
+		word = arg
+		candidates = []string{"on", "off"}
+	} else {
+		return
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+	completion := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, completion) {
+			completion = completion[:len(completion)-1]
+		}
+	}
+	if len(matches) == 1 {
+		completion += " "
+	} else {
+		p.message = strings.Join(matches, "  ")
+	}
+	for _, r := range completion[len(word):] {
+		p.insert(r)
+	}
+}
+
+// line returns the prompt line to draw: the input with its cursor while
+// open, otherwise the last message (empty when there is nothing to show)
+func (p *palette) line() string {
+	if !p.open {
+		return p.message
+	}
+	var b strings.Builder
+	b.WriteString(":")
+	b.WriteString(string(p.text[:p.cursor]))
+	// Draw the cursor in reverse video, as the terminal cursor is hidden
+	cursor := " "
+	if p.cursor < len(p.text) {
+		cursor = string(p.text[p.cursor])
+	}
+	b.WriteString(reverseVideo + cursor + noReverseVideo)
+	if p.cursor < len(p.text) {
+		b.WriteString(string(p.text[p.cursor+1:]))
+	}
+	if p.message != "" {
+		b.WriteString("   " + p.message)
+	}
+	return b.String()
+}
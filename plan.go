@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// phasePlan is a sequence of countdown phases run back to back, such as a
+// pomodoro cycle, a repeated countdown or chained segments
+type phasePlan interface {
+	mode() string                   // session mode, e.g. "pomodoro"
+	phase() (string, time.Duration) // label and length of the current phase
+	cycle() int                     // 1-based cycle or iteration of the current phase
+	header() string                 // line shown above the time
+	last() bool                     // whether the current phase is the final one
+	advance()                       // move on to the next phase
+	finishedMessage() string        // notification sent when the current phase ends
+	save(s *Session)                // store the plan and its position in a session
+}
+
+// planFromSession rebuilds the plan saved in a session, or nil if none
+func planFromSession(s Session) (phasePlan, error) {
+	switch {
+	case s.Pomodoro != nil:
+		return pomodoroPlanFromSession(s.Pomodoro)
+	case s.Repeat != nil:
+		return repeatPlanFromSession(s.Repeat)
+	case s.Segments != nil:
+		return segmentPlanFromSession(s.Segments)
+	}
+	return nil, nil
+}
+
+// repeatPlan runs the same countdown a number of times (or forever)
+type repeatPlan struct {
+	duration  time.Duration
+	count     int // number of iterations, 0 repeats forever
+	iteration int // 1-based current iteration
+}
+
+func newRepeatPlan(duration time.Duration, count int) (*repeatPlan, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("repeat needs a countdown duration")
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("repeat count must be positive")
+	}
+	return &repeatPlan{duration: duration, count: count, iteration: 1}, nil
+}
+
+// parseRepeatCount parses a -repeat value: a positive count or "inf"
+func parseRepeatCount(s string) (int, error) {
+	if s == "inf" || s == "forever" {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(s)
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("invalid repeat count %q, expected a positive number or inf", s)
+	}
+	return count, nil
+}
+
+// repeatPlanFromSession rebuilds a plan saved in sessions.json
+func repeatPlanFromSession(s *RepeatSession) (*repeatPlan, error) {
+	plan, err := newRepeatPlan(parseFormattedDuration(s.Duration), s.Count)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repeat session: %w", err)
+	}
+	if s.Iteration < 1 || (s.Count > 0 && s.Iteration > s.Count) {
+		return nil, fmt.Errorf("invalid repeat session: iteration %d out of range", s.Iteration)
+	}
+	plan.iteration = s.Iteration
+	return plan, nil
+}
+
+func (p *repeatPlan) mode() string { return "repeat" }
+
+func (p *repeatPlan) phase() (string, time.Duration) {
+	return "Iteration", p.duration
+}
+
+func (p *repeatPlan) cycle() int { return p.iteration }
+
+// progress returns the iteration counter, e.g. "3/8" or "3/∞"
+func (p *repeatPlan) progress() string {
+	if p.count == 0 {
+		return fmt.Sprintf("%d/∞", p.iteration)
+	}
+	return fmt.Sprintf("%d/%d", p.iteration, p.count)
+}
+
+func (p *repeatPlan) header() string {
+	return "Iteration " + p.progress()
+}
+
+func (p *repeatPlan) last() bool {
+	return p.count > 0 && p.iteration >= p.count
+}
+
+func (p *repeatPlan) advance() {
+	p.iteration++
+}
+
+func (p *repeatPlan) finishedMessage() string {
+	return fmt.Sprintf("Timer finished! (%s)", p.progress())
+}
+
+func (p *repeatPlan) save(s *Session) {
+	s.Repeat = &RepeatSession{
+		Duration:  formatDuration(p.duration),
+		Count:     p.count,
+		Iteration: p.iteration,
+	}
+}
+
+// segment is one labelled countdown of a chained run
+type segment struct {
+	label    string
+	duration time.Duration
+	context  string // where the segment sits in a sequence, e.g. "round 3/8"
+}
+
+// parseSegmentArg parses a "25m:Write" command-line segment. The label
+// follows the last colon unless that part is numeric, so clock-style
+// durations keep working.
+func parseSegmentArg(arg string) (segment, error) {
+	spec, label := splitSegmentLabel(arg)
+	duration, err := parseDurationArg(spec)
+	if err != nil {
+		return segment{}, fmt.Errorf("invalid segment %q: %w", arg, err)
+	}
+	if duration <= 0 {
+		return segment{}, fmt.Errorf("invalid segment %q: duration must be positive", arg)
+	}
+	return segment{label: label, duration: duration}, nil
+}
+
+// splitSegmentLabel splits "25m:Write" into its duration and label. A
+// number after the last colon is clock notation ("1:30"), not a label.
+func splitSegmentLabel(arg string) (spec, label string) {
+	if i := strings.LastIndex(arg, ":"); i >= 0 {
+		if _, err := strconv.Atoi(arg[i+1:]); err != nil {
+			return arg[:i], strings.TrimSpace(arg[i+1:])
+		}
+	}
+	return arg, ""
+}
+
+// segmentPlan runs labelled countdowns back to back, given on the command
+// line or loaded from a sequence file
+type segmentPlan struct {
+	segments []segment
+	index    int
+	source   string // sequence file name, empty for command-line segments
+}
+
+func newSegmentPlan(segments []segment) (*segmentPlan, error) {
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no segments given")
+	}
+	for i := range segments {
+		if segments[i].label == "" {
+			segments[i].label = fmt.Sprintf("Segment %d", i+1)
+		}
+	}
+	return &segmentPlan{segments: segments}, nil
+}
+
+// segmentPlanFromSession rebuilds a plan saved in sessions.json
+func segmentPlanFromSession(s *SegmentsSession) (*segmentPlan, error) {
+	segments := make([]segment, 0, len(s.Segments))
+	for _, spec := range s.Segments {
+		segments = append(segments, segment{
+			label:    spec.Label,
+			duration: parseFormattedDuration(spec.Duration),
+			context:  spec.Context,
+		})
+	}
+	plan, err := newSegmentPlan(segments)
+	if err != nil {
+		return nil, fmt.Errorf("invalid segments session: %w", err)
+	}
+	if s.Index < 0 || s.Index >= len(segments) {
+		return nil, fmt.Errorf("invalid segments session: segment %d out of range", s.Index)
+	}
+	plan.index = s.Index
+	plan.source = s.Source
+	return plan, nil
+}
+
+func (p *segmentPlan) mode() string {
+	if p.source != "" {
+		return "sequence"
+	}
+	return "segments"
+}
+
+func (p *segmentPlan) phase() (string, time.Duration) {
+	current := p.segments[p.index]
+	return current.label, current.duration
+}
+
+func (p *segmentPlan) cycle() int { return p.index + 1 }
+
+// header shows the current segment, its position and the next segment,
+// e.g. "Write (1/3) · next: Break" or "Work · round 3/8 (6/18) · next: Rest"
+func (p *segmentPlan) header() string {
+	current := p.segments[p.index]
+	header := current.label
+	if current.context != "" {
+		header += " · " + current.context
+	}
+	header += fmt.Sprintf(" (%d/%d)", p.index+1, len(p.segments))
+	if !p.last() {
+		header += " · next: " + p.segments[p.index+1].label
+	}
+	return header
+}
+
+func (p *segmentPlan) last() bool {
+	return p.index == len(p.segments)-1
+}
+
+func (p *segmentPlan) advance() {
+	p.index++
+}
+
+func (p *segmentPlan) finishedMessage() string {
+	if p.last() {
+		return fmt.Sprintf("%s finished, all segments done!", p.segments[p.index].label)
+	}
+	return fmt.Sprintf("%s finished, next: %s", p.segments[p.index].label, p.segments[p.index+1].label)
+}
+
+func (p *segmentPlan) save(s *Session) {
+	specs := make([]SegmentSpec, 0, len(p.segments))
+	for _, seg := range p.segments {
+		specs = append(specs, SegmentSpec{
+			Duration: formatDuration(seg.duration),
+			Label:    seg.label,
+			Context:  seg.context,
+		})
+	}
+	s.Segments = &SegmentsSession{Segments: specs, Index: p.index, Source: p.source}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pomodoro phase labels
+const (
+	phaseWork       = "Work"
+	phaseShortBreak = "Short break"
+	phaseLongBreak  = "Long break"
+)
+
+// pomodoroPlan describes the work/break rhythm of a pomodoro cycle.
+// Even phase indices are work phases, odd indices are breaks.
+type pomodoroPlan struct {
+	work           time.Duration
+	shortBreak     time.Duration
+	longBreak      time.Duration
+	longBreakEvery int // a long break follows every N-th work phase
+	index          int // current phase index
+}
+
+func newPomodoroPlan(work, shortBreak, longBreak time.Duration, longBreakEvery int) (*pomodoroPlan, error) {
+	if work <= 0 || shortBreak <= 0 || longBreak <= 0 {
+		return nil, fmt.Errorf("pomodoro phase lengths must be positive")
+	}
+	if longBreakEvery < 1 {
+		return nil, fmt.Errorf("long break interval must be at least 1")
+	}
+	return &pomodoroPlan{
+		work:           work,
+		shortBreak:     shortBreak,
+		longBreak:      longBreak,
+		longBreakEvery: longBreakEvery,
+	}, nil
+}
+
+// pomodoroPlanFromSession rebuilds a plan saved in sessions.json
+func pomodoroPlanFromSession(s *PomodoroSession) (*pomodoroPlan, error) {
+	plan, err := newPomodoroPlan(
+		parseFormattedDuration(s.Work),
+		parseFormattedDuration(s.ShortBreak),
+		parseFormattedDuration(s.LongBreak),
+		s.LongBreakEvery,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pomodoro session: %w", err)
+	}
+	if s.PhaseIndex < 0 {
+		return nil, fmt.Errorf("invalid pomodoro session: phase index %d out of range", s.PhaseIndex)
+	}
+	plan.index = s.PhaseIndex
+	// The saved label and cycle must agree with the index
+	if label, _ := plan.phase(); label != s.Phase || plan.cycle() != s.Cycle {
+		return nil, fmt.Errorf("invalid pomodoro session: phase index %d is not %s of cycle %d", s.PhaseIndex, s.Phase, s.Cycle)
+	}
+	return plan, nil
+}
+
+// cycle returns the 1-based number of the current work/break pair
+func (p *pomodoroPlan) cycle() int {
+	return p.index/2 + 1
+}
+
+// phase returns the label and length of the current phase
+func (p *pomodoroPlan) phase() (string, time.Duration) {
+	if p.index%2 == 0 {
+		return phaseWork, p.work
+	}
+	if p.cycle()%p.longBreakEvery == 0 {
+		return phaseLongBreak, p.longBreak
+	}
+	return phaseShortBreak, p.shortBreak
+}
+
+func (p *pomodoroPlan) mode() string { return "pomodoro" }
+
+func (p *pomodoroPlan) header() string {
+	label, _ := p.phase()
+	return fmt.Sprintf("%s · cycle %d", label, p.cycle())
+}
+
+// last is always false: a pomodoro cycle runs until quit
+func (p *pomodoroPlan) last() bool { return false }
+
+func (p *pomodoroPlan) advance() {
+	p.index++
+}
+
+// nextLabel returns the label of the phase following the current one
+func (p *pomodoroPlan) nextLabel() string {
+	next := *p
+	next.index++
+	label, _ := next.phase()
+	return label
+}
+
+func (p *pomodoroPlan) finishedMessage() string {
+	label, _ := p.phase()
+	return fmt.Sprintf("%s finished, next: %s", label, p.nextLabel())
+}
+
+func (p *pomodoroPlan) save(s *Session) {
+	label, _ := p.phase()
+	s.Pomodoro = &PomodoroSession{
+		Work:           formatDuration(p.work),
+		ShortBreak:     formatDuration(p.shortBreak),
+		LongBreak:      formatDuration(p.longBreak),
+		LongBreakEvery: p.longBreakEvery,
+		PhaseIndex:     p.index,
+		Phase:          label,
+		Cycle:          p.cycle(),
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Sequence files describe agendas and workouts as steps and nested repeats:
+//
+//	# HIIT
+//	5m Warm up
+//	repeat 8 { 20s Work; 10s Rest }
+//	5m "Cool down"
+//
+// A step is a duration followed by an optional label, ended by a newline,
+// ';' or '}'. Everything after '#' on a line is a comment.
+
+// maxSequenceSteps bounds the expansion of nested repeats
+const maxSequenceSteps = 10000
+
+type seqTokenKind int
+
+const (
+	seqWord seqTokenKind = iota
+	seqString
+	seqOpen
+	seqClose
+	seqSep // ';' or newline
+	seqEOF
+)
+
+type seqToken struct {
+	kind      seqTokenKind
+	text      string
+	line, col int
+}
+
+// seqNode is a parsed step, or a repeat block when body is set
+type seqNode struct {
+	tok   seqToken // first token, for error positions
+	step  segment
+	count int
+	body  []seqNode
+}
+
+type seqParser struct {
+	path   string
+	tokens []seqToken
+	pos    int
+}
+
+func (p *seqParser) errorf(tok seqToken, format string, args ...any) error {
+	return fmt.Errorf("%s:%d:%d: %s", p.path, tok.line, tok.col, fmt.Sprintf(format, args...))
+}
+
+// tokenizeSequence splits a sequence file into tokens with their positions
+func tokenizeSequence(path, src string) ([]seqToken, error) {
+	var tokens []seqToken
+	line, col := 1, 1
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := seqToken{line: line, col: col}
+		switch {
+		case r == '\n':
+			start.kind = seqSep
+			tokens = append(tokens, start)
+			i++
+			line, col = line+1, 1
+			continue
+		case r == ' ' || r == '\t' || r == '\r':
+			i++
+			col++
+			continue
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case r == ';':
+			start.kind = seqSep
+		case r == '{':
+			start.kind = seqOpen
+		case r == '}':
+			start.kind = seqClose
+		case r == '"':
+			var text strings.Builder
+			i++
+			col++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\n' {
+					return nil, fmt.Errorf("%s:%d:%d: unterminated string", path, start.line, start.col)
+				}
+				text.WriteRune(runes[i])
+				i++
+				col++
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("%s:%d:%d: unterminated string", path, start.line, start.col)
+			}
+			start.kind = seqString
+			start.text = text.String()
+		default:
+			var text strings.Builder
+			for i < len(runes) && !strings.ContainsRune(" \t\r\n;{}#\"", runes[i]) {
+				text.WriteRune(runes[i])
+				i++
+				col++
+			}
+			start.kind = seqWord
+			start.text = text.String()
+			tokens = append(tokens, start)
+			continue
+		}
+		tokens = append(tokens, start)
+		i++
+		col++
+	}
+	return append(tokens, seqToken{kind: seqEOF, line: line, col: col}), nil
+}
+
+func (p *seqParser) peek() seqToken {
+	return p.tokens[p.pos]
+}
+
+func (p *seqParser) next() seqToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != seqEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// skipSeps skips separators (newlines and ';')
+func (p *seqParser) skipSeps() {
+	for p.peek().kind == seqSep {
+		p.next()
+	}
+}
+
+// parseItems parses steps and repeats until EOF, or until the '}' closing
+// the repeat opened at open
+func (p *seqParser) parseItems(open *seqToken) ([]seqNode, error) {
+	var nodes []seqNode
+	for {
+		p.skipSeps()
+		tok := p.peek()
+		switch tok.kind {
+		case seqEOF:
+			if open != nil {
+				return nil, p.errorf(*open, "missing '}' for this '{'")
+			}
+			return nodes, nil
+		case seqClose:
+			if open == nil {
+				return nil, p.errorf(tok, "unexpected '}'")
+			}
+			p.next()
+			return nodes, nil
+		case seqWord:
+			var node seqNode
+			var err error
+			if tok.text == "repeat" {
+				node, err = p.parseRepeat()
+			} else {
+				node, err = p.parseStep()
+			}
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		default:
+			return nil, p.errorf(tok, "expected a step or repeat")
+		}
+	}
+}
+
+// parseRepeat parses "repeat N { ... }"
+func (p *seqParser) parseRepeat() (seqNode, error) {
+	node := seqNode{tok: p.next()}
+	countTok := p.next()
+	count, err := strconv.Atoi(countTok.text)
+	if countTok.kind != seqWord || err != nil || count < 1 {
+		return node, p.errorf(countTok, "expected a positive repeat count")
+	}
+	node.count = count
+
+	// Allow the brace on the following line
+	p.skipSeps()
+	open := p.next()
+	if open.kind != seqOpen {
+		return node, p.errorf(open, "expected '{' after repeat count")
+	}
+	node.body, err = p.parseItems(&open)
+	if err != nil {
+		return node, err
+	}
+	if len(node.body) == 0 {
+		return node, p.errorf(node.tok, "empty repeat block")
+	}
+	return node, nil
+}
+
+// parseStep parses "<duration> [label]". The duration may be spread over
+// several words: a number and its unit ("90 min"), followed by parts that
+// each carry a unit ("1h 30m"). The label starts at the first other word.
+func (p *seqParser) parseStep() (seqNode, error) {
+	node := seqNode{tok: p.next()}
+	text := node.tok.text
+	if _, err := strconv.ParseFloat(text, 64); err == nil && p.nextIsUnit() {
+		text += " " + p.next().text
+	}
+	for p.extendsDuration(text) {
+		text += " " + p.next().text
+	}
+	duration, err := parseDurationArg(text)
+	if err != nil {
+		return node, p.errorf(node.tok, "%v", err)
+	}
+	if duration <= 0 {
+		return node, p.errorf(node.tok, "duration %q must be positive", text)
+	}
+	node.step.duration = duration
+
+	var words []string
+	for {
+		tok := p.peek()
+		switch tok.kind {
+		case seqWord, seqString:
+			words = append(words, p.next().text)
+			continue
+		case seqOpen:
+			return node, p.errorf(tok, "unexpected '{' in step label")
+		}
+		break
+	}
+	node.step.label = strings.Join(words, " ")
+	return node, nil
+}
+
+// nextIsUnit reports whether the next word is a unit name such as "min"
+func (p *seqParser) nextIsUnit() bool {
+	tok := p.peek()
+	_, ok := durationUnits[strings.ToLower(tok.text)]
+	return tok.kind == seqWord && ok
+}
+
+// extendsDuration reports whether the next word is a duration part with a
+// unit that continues text, so "5m 2 days off" stays 5m labelled "2 days off"
+func (p *seqParser) extendsDuration(text string) bool {
+	tok := p.peek()
+	if tok.kind != seqWord {
+		return false
+	}
+	if _, err := parseDurationArg(tok.text); err != nil {
+		return false
+	}
+	_, err := parseDurationArg(text + " " + tok.text)
+	return err == nil
+}
+
+// expandSequence flattens steps and repeats into segments, recording the
+// round of each enclosing repeat (e.g. "round 3/8")
+func (p *seqParser) expandSequence(nodes []seqNode, rounds []string, segments []segment) ([]segment, error) {
+	for _, node := range nodes {
+		if node.body == nil {
+			seg := node.step
+			if len(rounds) > 0 {
+				seg.context = "round " + strings.Join(rounds, " · ")
+			}
+			segments = append(segments, seg)
+			if len(segments) > maxSequenceSteps {
+				return nil, p.errorf(node.tok, "sequence expands to more than %d steps", maxSequenceSteps)
+			}
+			continue
+		}
+		for i := 1; i <= node.count; i++ {
+			var err error
+			round := fmt.Sprintf("%d/%d", i, node.count)
+			segments, err = p.expandSequence(node.body, append(rounds[:len(rounds):len(rounds)], round), segments)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return segments, nil
+}
+
+// parseSequence parses a sequence file into the segments it runs
+func parseSequence(path, src string) ([]segment, error) {
+	tokens, err := tokenizeSequence(path, src)
+	if err != nil {
+		return nil, err
+	}
+	p := &seqParser{path: path, tokens: tokens}
+	nodes, err := p.parseItems(nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("%s: no steps", path)
+	}
+	return p.expandSequence(nodes, nil, nil)
+}
+
+// loadSequence reads a sequence file and builds its plan
+func loadSequence(path string) (*segmentPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sequence: %w", err)
+	}
+	segments, err := parseSequence(path, string(data))
+	if err != nil {
+		return nil, err
+	}
+	plan, err := newSegmentPlan(segments)
+	if err != nil {
+		return nil, err
+	}
+	plan.source = filepath.Base(path)
+	return plan, nil
+}
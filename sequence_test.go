@@ -0,0 +1,95 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSequenceSteps(t *testing.T) {
+	tests := []struct {
+		src  string
+		want segment
+	}{
+		{"25m", segment{duration: 25 * time.Minute}},
+		{"25m Write", segment{label: "Write", duration: 25 * time.Minute}},
+		{"90 min Work", segment{label: "Work", duration: 90 * time.Minute}},
+		{"90 Min", segment{duration: 90 * time.Minute}},
+		{"1h 30m Rest", segment{label: "Rest", duration: 90 * time.Minute}},
+		{"2 days 4h off", segment{label: "off", duration: 52 * time.Hour}},
+		{"1:30 Plank", segment{label: "Plank", duration: 90 * time.Second}},
+		{"45 Squats", segment{label: "Squats", duration: 45 * time.Second}},
+		{`5m "Cool down"`, segment{label: "Cool down", duration: 5 * time.Minute}},
+
+		// The duration ends once a part has its unit
+		{"5m 2 days off", segment{label: "2 days off", duration: 5 * time.Minute}},
+		{"1h 30 min Rest", segment{label: "30 min Rest", duration: time.Hour}},
+		{"5m 10 Push-ups", segment{label: "10 Push-ups", duration: 5 * time.Minute}},
+		{"30s s", segment{label: "s", duration: 30 * time.Second}},
+	}
+	for _, tt := range tests {
+		segments, err := parseSequence("test.seq", tt.src)
+		if err != nil {
+			t.Errorf("parseSequence(%q): %v", tt.src, err)
+		} else if len(segments) != 1 || segments[0] != tt.want {
+			t.Errorf("parseSequence(%q) = %+v, want %+v", tt.src, segments, tt.want)
+		}
+	}
+}
+
+func TestParseSequenceRepeats(t *testing.T) {
+	src := `# HIIT
+5m Warm up
+repeat 2 { 20s Work; repeat 2
+  { 10s Rest }
+}
+5m "Cool down" # done
+`
+	segments, err := parseSequence("hiit.seq", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range segments {
+		got = append(got, strings.TrimSpace(s.duration.String()+" "+s.label+" "+s.context))
+	}
+	want := []string{
+		"5m0s Warm up",
+		"20s Work round 1/2",
+		"10s Rest round 1/2 · 1/2",
+		"10s Rest round 1/2 · 2/2",
+		"20s Work round 2/2",
+		"10s Rest round 2/2 · 1/2",
+		"10s Rest round 2/2 · 2/2",
+		"5m0s Cool down",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseSequence = %q, want %q", got, want)
+	}
+}
+
+func TestParseSequenceErrors(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"", "test.seq: no steps"},
+		{"# only a comment\n", "test.seq: no steps"},
+		{"5x Work", "test.seq:1:1: invalid duration"},
+		{"0s Nothing", "test.seq:1:1: duration \"0s\" must be positive"},
+		{"5m\nrepeat 0 { 1m }", "test.seq:2:8: expected a positive repeat count"},
+		{"repeat 2 1m", "test.seq:1:10: expected '{' after repeat count"},
+		{"repeat 2 {\n 1m", "test.seq:1:10: missing '}' for this '{'"},
+		{"repeat 2 { }", "test.seq:1:1: empty repeat block"},
+		{"1m }", "test.seq:1:4: unexpected '}'"},
+		{"1m Work {", "test.seq:1:9: unexpected '{' in step label"},
+		{`1m "Work`, "test.seq:1:4: unterminated string"},
+		{"repeat 10000 { 1s; 1s }", "sequence expands to more than 10000 steps"},
+	}
+	for _, tt := range tests {
+		_, err := parseSequence("test.seq", tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseSequence(%q) error %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// parseSince parses the start of 'timer since' (see parseWallClock). A bare
+// time means its last occurrence, today or yesterday.
+func parseSince(args []string, now time.Time) (time.Time, error) {
+	start, bare, err := parseWallClock(args, now, "since")
+	if err != nil {
+		return time.Time{}, err
+	}
+	if bare && start.After(now) {
+		start, _, err = parseWallClock(append([]string{"yesterday"}, args...), now, "since")
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	if start.After(now) {
+		return time.Time{}, fmt.Errorf("%s is in the future", start.Format("2006-01-02 15:04:05"))
+	}
+	return start, nil
+}
+
+// sinceFile returns the file storing named since timers, next to the history
+func sinceFile() (string, error) {
+	dir, err := historyDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "since.json"), nil
+}
+
+// loadSinceTimers reads the named since timers and their start times
+func loadSinceTimers() (map[string]time.Time, error) {
+	path, err := sinceFile()
+	if err != nil {
+		return nil, err
+	}
+	timers := make(map[string]time.Time)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return timers, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read since timers: %w", err)
+	}
+	if err := json.Unmarshal(data, &timers); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return timers, nil
+}
+
+// saveSinceTimers replaces the stored since timers
+func saveSinceTimers(timers map[string]time.Time) error {
+	path, err := sinceFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create data dir: %w", err)
+	}
+	data, err := json.MarshalIndent(timers, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write since timers: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// sinceStart resolves where 'timer since' starts counting. A named timer
+// given a time is stored so that 'timer since -name NAME' picks it up again
+// after restarts; without a time the stored start is used.
+func sinceStart(args []string, name string, now time.Time) (time.Time, error) {
+	if len(args) == 0 && name != "" {
+		timers, err := loadSinceTimers()
+		if err != nil {
+			return time.Time{}, err
+		}
+		start, ok := timers[name]
+		if !ok {
+			return time.Time{}, fmt.Errorf("no since timer named %q, start it with 'timer since -name %s <time>'", name, name)
+		}
+		return start, nil
+	}
+
+	start, err := parseSince(args, now)
+	if err != nil || name == "" {
+		return start, err
+	}
+	timers, err := loadSinceTimers()
+	if err != nil {
+		return time.Time{}, err
+	}
+	timers[name] = start
+	return start, saveSinceTimers(timers)
+}
+
+// runSinceCommand implements 'timer since list' and 'timer since clear NAME'
+func runSinceCommand(args []string) error {
+	timers, err := loadSinceTimers()
+	if err != nil {
+		return err
+	}
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		names := make([]string, 0, len(timers))
+		for name := range timers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		now := time.Now()
+		for _, name := range names {
+			start := timers[name]
+			fmt.Printf("%-20s %s  %s\n", name, start.Format("2006-01-02 15:04:05"), formatHMS(now.Sub(start)))
+		}
+		return nil
+	case args[0] == "clear" && len(args) == 2:
+		if _, ok := timers[args[1]]; !ok {
+			return fmt.Errorf("no since timer named %q", args[1])
+		}
+		delete(timers, args[1])
+		return saveSinceTimers(timers)
+	}
+	return fmt.Errorf("usage: timer since list | timer since clear <name>")
+}
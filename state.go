@@ -0,0 +1,368 @@
+package main
+
+import (
+	"slices"
+	"time"
+)
+
+// sessionTimeLayout is the timestamp layout used in sessions.json
+const sessionTimeLayout = "2006-01-02:15-04-05"
+
+// timerState holds the clock of a running timer independently of the terminal
+type timerState struct {
+	name     string
+	duration time.Duration // length of the current countdown, 0 in counter mode
+	inline   bool
+	overtime bool          // keep counting past zero instead of finishing
+	since    time.Time     // start of a since timer, which counts on across restarts
+	until    time.Time     // target of an until timer, moved by pauses and adjustments
+	carried  time.Duration // elapsed time a since timer starts with, left out of the summary
+
+	runStart    time.Time // when the whole run started
+	start       time.Time // when the current phase started (shifted back on restore)
+	paused      bool
+	pauseStart  time.Time
+	totalPaused time.Duration // paused time within the current phase
+
+	// Multi-phase plan such as a pomodoro cycle (nil for single countdowns
+	// and counters)
+	plan          phasePlan
+	phases        []PhaseRecord // completed phases, oldest first
+	phasesElapsed time.Duration // effective time spent in completed phases
+	phasesPaused  time.Duration // paused time spent in completed phases and before restarts
+
+	// Laps recorded in counter mode
+	laps []LapRecord
+
+	// Time added or removed while running, oldest first
+	adjustments []AdjustmentRecord
+
+	// Resets and restarts, and the elapsed time they discarded, which still
+	// counts as run time
+	restarts  []RestartRecord
+	discarded time.Duration
+
+	// Finished with the finish key before the countdown (or plan) ran out
+	early bool
+
+	// Intermediate alerts of the current countdown and the labels of those
+	// already fired (or passed before the countdown started)
+	alerts  []alertMark
+	alerted []string
+}
+
+func newTimerState(opts timerOptions, now time.Time) *timerState {
+	st := &timerState{
+		name:     opts.name,
+		duration: opts.duration,
+		inline:   !opts.fullscreen,
+		overtime: opts.overtime,
+		since:    opts.since,
+		until:    opts.until,
+		start:    now.Add(-opts.initialElapsed),
+		paused:   opts.paused,
+		plan:     opts.plan,
+		phases:   opts.phases,
+		laps:     opts.laps,
+		alerts:   opts.alerts,
+		alerted:  opts.alerted,
+
+		adjustments: opts.adjustments,
+		restarts:    opts.restarts,
+	}
+	for _, p := range opts.phases {
+		st.phasesElapsed += parseFormattedDuration(p.Elapsed)
+	}
+	for _, r := range opts.restarts {
+		st.discarded += parseFormattedDuration(r.Elapsed)
+	}
+	if !st.since.IsZero() {
+		// Only the time this run was open counts in the summary and history
+		st.carried = opts.initialElapsed
+	}
+	st.runStart = st.start.Add(-st.phasesElapsed - st.discarded + st.carried)
+	if st.paused {
+		st.pauseStart = now
+	}
+	st.dueAlerts(now) // never fire alerts the countdown starts past
+	return st
+}
+
+// stateFromSession rebuilds a timer state from a session snapshot taken at now,
+// e.g. to display a timer owned by the daemon
+func stateFromSession(s Session, now time.Time) *timerState {
+	elapsed := parseFormattedDuration(s.Elapsed)
+	st := &timerState{
+		name:   s.Name,
+		inline: s.Inline,
+		start:  now.Add(-elapsed),
+		paused: s.Paused,
+		laps:   s.Laps,
+	}
+	if s.Mode != "counter" {
+		st.duration = elapsed + parseFormattedDuration(s.Remaining)
+	}
+	if s.Overtime != "" {
+		st.overtime = true
+		st.duration -= parseFormattedDuration(s.Overtime)
+	}
+	// Malformed times leave the zero time, as for a plain timer
+	st.since, _ = time.Parse(time.RFC3339, s.Since)
+	st.until, _ = time.Parse(time.RFC3339, s.Until)
+	st.runStart = st.start
+	st.pauseStart = now
+	return st
+}
+
+func (st *timerState) isCounter() bool {
+	return st.duration == 0
+}
+
+func (st *timerState) mode() string {
+	if st.plan != nil {
+		return st.plan.mode()
+	}
+	if st.isCounter() {
+		return "counter"
+	}
+	return "timer"
+}
+
+// elapsed returns the effective time spent in the current phase, excluding pauses
+func (st *timerState) elapsed(now time.Time) time.Duration {
+	elapsed := now.Sub(st.start) - st.totalPaused
+	if st.paused {
+		elapsed -= now.Sub(st.pauseStart)
+	}
+	return elapsed
+}
+
+// remaining returns the time left in the current countdown, never negative
+func (st *timerState) remaining(now time.Time) time.Duration {
+	remaining := st.duration - st.elapsed(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining
+}
+
+// done reports whether the current countdown has run out
+func (st *timerState) done(now time.Time) bool {
+	return !st.isCounter() && st.elapsed(now) >= st.duration
+}
+
+// overrun returns how far an overtime countdown has run past zero
+func (st *timerState) overrun(now time.Time) time.Duration {
+	if !st.overtime || st.isCounter() {
+		return 0
+	}
+	return max(st.elapsed(now)-st.duration, 0)
+}
+
+// paused time accumulated over the whole run, including an ongoing pause
+func (st *timerState) pausedTotal(now time.Time) time.Duration {
+	total := st.phasesPaused + st.totalPaused
+	if st.paused {
+		total += now.Sub(st.pauseStart)
+	}
+	return total
+}
+
+func (st *timerState) togglePause(now time.Time) {
+	if st.paused {
+		st.totalPaused += now.Sub(st.pauseStart)
+		st.until = movedUntil(st.until, now.Sub(st.pauseStart))
+		st.paused = false
+	} else {
+		st.paused = true
+		st.pauseStart = now
+	}
+}
+
+// addTime extends (or with a negative d shortens) the current countdown.
+// In counter mode it moves the elapsed time instead. The result never
+// goes below zero remaining (countdown) or zero elapsed (counter). The
+// change actually applied is logged as an adjustment.
+func (st *timerState) addTime(d time.Duration, now time.Time) {
+	elapsed := st.elapsed(now)
+	if st.isCounter() {
+		if elapsed+d < 0 {
+			d = -elapsed
+		}
+		st.start = st.start.Add(-d)
+		st.runStart = st.runStart.Add(-d)
+	} else {
+		// Keep the duration non-zero so the timer doesn't turn into a counter
+		previous := st.duration
+		st.duration = max(st.duration+d, elapsed, time.Nanosecond)
+		d = st.duration - previous
+	}
+	st.until = movedUntil(st.until, d)
+	if d != 0 {
+		st.adjustments = append(st.adjustments, AdjustmentRecord{
+			Time:    now.Format(sessionTimeLayout),
+			Elapsed: formatDuration(elapsed),
+			Delta:   formatDuration(d),
+		})
+	}
+}
+
+// movedUntil moves the target of an until timer by d, leaving the zero
+// time of other timers alone
+func movedUntil(until time.Time, d time.Duration) time.Time {
+	if until.IsZero() {
+		return until
+	}
+	return until.Add(d)
+}
+
+// restart starts the current countdown (or the stopwatch) over from zero,
+// keeping its length and paused state, and logs it as the given action
+// ("reset" or "restart"). Laps and fired alerts are cleared.
+func (st *timerState) restart(action string, now time.Time) {
+	elapsed := st.elapsed(now)
+	st.restarts = append(st.restarts, RestartRecord{
+		Time:    now.Format(sessionTimeLayout),
+		Action:  action,
+		Elapsed: formatDuration(elapsed),
+	})
+	st.discarded += elapsed
+	st.phasesPaused = st.pausedTotal(now)
+	st.start = now
+	st.totalPaused = 0
+	if st.paused {
+		st.pauseStart = now
+	}
+	st.laps = nil
+	st.alerted = nil
+	st.dueAlerts(now)
+}
+
+// dueAlerts returns the alerts the countdown has passed since the last call
+// and marks them as fired
+func (st *timerState) dueAlerts(now time.Time) []alertMark {
+	if st.isCounter() {
+		return nil
+	}
+	var due []alertMark
+	elapsed := st.elapsed(now)
+	for _, m := range st.alerts {
+		if m.passed(elapsed, st.duration) && !slices.Contains(st.alerted, m.label) {
+			st.alerted = append(st.alerted, m.label)
+			due = append(due, m)
+		}
+	}
+	return due
+}
+
+// lap records a lap at the current elapsed time and returns it
+func (st *timerState) lap(now time.Time) LapRecord {
+	split := st.elapsed(now)
+	lap := split
+	if len(st.laps) > 0 {
+		lap -= parseFormattedDuration(st.laps[len(st.laps)-1].Split)
+	}
+	record := LapRecord{
+		Number: len(st.laps) + 1,
+		Lap:    formatDuration(lap),
+		Split:  formatDuration(split),
+	}
+	st.laps = append(st.laps, record)
+	return record
+}
+
+// phaseRecord describes the current plan phase as ended at now
+func (st *timerState) phaseRecord(now time.Time) PhaseRecord {
+	label, planned := st.plan.phase()
+	return PhaseRecord{
+		Phase:   label,
+		Cycle:   st.plan.cycle(),
+		Start:   st.start.Format(sessionTimeLayout),
+		End:     now.Format(sessionTimeLayout),
+		Elapsed: formatDuration(st.elapsed(now)),
+		Planned: formatDuration(planned),
+	}
+}
+
+// nextPhase records the current plan phase as completed (or skipped before
+// it ran out) and starts the next one
+func (st *timerState) nextPhase(now time.Time, skipped bool) {
+	elapsed := st.elapsed(now)
+	record := st.phaseRecord(now)
+	record.Skipped = skipped
+	st.phases = append(st.phases, record)
+	st.phasesElapsed += elapsed
+	st.phasesPaused += st.totalPaused
+
+	st.plan.advance()
+	_, st.duration = st.plan.phase()
+	st.start = now
+	st.totalPaused = 0
+	st.paused = false
+
+	// Alerts apply to each phase
+	st.alerted = nil
+	st.dueAlerts(now)
+}
+
+// session builds the sessions.json snapshot of the current state
+func (st *timerState) session(now time.Time, finished bool) Session {
+	session := Session{
+		Start:    st.start.Format(sessionTimeLayout),
+		Current:  now.Format(sessionTimeLayout),
+		Elapsed:  formatDuration(st.elapsed(now)),
+		Paused:   st.paused && !finished,
+		Mode:     st.mode(),
+		Name:     st.name,
+		Finished: finished,
+		Early:    finished && st.early,
+		Inline:   st.inline,
+		Phases:   st.phases,
+		Laps:     st.laps,
+		Alerts:   st.alerted,
+
+		Adjustments: st.adjustments,
+		Restarts:    st.restarts,
+	}
+	if !st.isCounter() {
+		session.Remaining = formatDuration(st.remaining(now))
+	}
+	if st.overtime && !st.isCounter() {
+		session.Overtime = formatDuration(st.overrun(now))
+	}
+	if !st.since.IsZero() {
+		session.Since = st.since.Format(time.RFC3339)
+	}
+	if !st.until.IsZero() {
+		session.Until = st.until.Format(time.RFC3339)
+	}
+	if st.plan != nil {
+		st.plan.save(&session)
+	}
+	return session
+}
+
+// summary builds the summary printed once the timer exits. Its phases
+// include the phase in progress.
+func (st *timerState) summary(now time.Time, finished bool) TimerSummary {
+	phases := st.phases
+	if st.plan != nil {
+		phases = append(slices.Clip(phases), st.phaseRecord(now))
+	}
+	return TimerSummary{
+		Start:    st.runStart,
+		End:      now,
+		Duration: st.phasesElapsed + st.discarded + st.elapsed(now) - st.carried,
+		Paused:   st.pausedTotal(now),
+		Mode:     st.mode(),
+		Finished: finished,
+		Early:    finished && st.early,
+		Name:     st.name,
+		Phases:   phases,
+		Laps:     st.laps,
+		Overtime: st.overrun(now),
+
+		Adjustments: st.adjustments,
+		Restarts:    st.restarts,
+	}
+}
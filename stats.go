@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// statsGroup aggregates the history records sharing one key (day, week, ...)
+type statsGroup struct {
+	key       string
+	sessions  int
+	completed int
+	total     time.Duration // focused time (see focusedTime)
+	longest   time.Duration
+	paused    time.Duration
+}
+
+// add accounts one history record in the group
+func (g *statsGroup) add(r HistoryRecord) {
+	d := r.focusedTime()
+	g.sessions++
+	if r.Finished {
+		g.completed++
+	}
+	g.total += d
+	g.paused += parseFormattedDuration(r.Paused)
+	if d > g.longest {
+		g.longest = d
+	}
+}
+
+func (g *statsGroup) average() time.Duration {
+	if g.sessions == 0 {
+		return 0
+	}
+	return g.total / time.Duration(g.sessions)
+}
+
+func (g *statsGroup) completionRatio() float64 {
+	if g.sessions == 0 {
+		return 0
+	}
+	return float64(g.completed) / float64(g.sessions)
+}
+
+// MarshalJSON reports durations in seconds for scripts
+func (g *statsGroup) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Key             string  `json:"key"`
+		Sessions        int     `json:"sessions"`
+		Completed       int     `json:"completed"`
+		CompletionRatio float64 `json:"completionRatio"`
+		TotalSeconds    float64 `json:"totalSeconds"`
+		AverageSeconds  float64 `json:"averageSeconds"`
+		LongestSeconds  float64 `json:"longestSeconds"`
+		PausedSeconds   float64 `json:"pausedSeconds"`
+	}{
+		Key:             g.key,
+		Sessions:        g.sessions,
+		Completed:       g.completed,
+		CompletionRatio: g.completionRatio(),
+		TotalSeconds:    g.total.Seconds(),
+		AverageSeconds:  g.average().Seconds(),
+		LongestSeconds:  g.longest.Seconds(),
+		PausedSeconds:   g.paused.Seconds(),
+	})
+}
+
+// statsKey returns the group key of a record for the given grouping
+func statsKey(r HistoryRecord, by string) string {
+	start := r.Start.Local()
+	switch by {
+	case "week":
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "month":
+		return start.Format("2006-01")
+	case "name":
+		if r.Name == "" {
+			return "(unnamed)"
+		}
+		return r.Name
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+// parseDateArg parses a YYYY-MM-DD date, "today" or "yesterday" as the
+// start of that day in the local time zone
+func parseDateArg(s string) (time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch s {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD, today or yesterday", s)
+	}
+	return t, nil
+}
+
+// runStats implements the 'timer stats' subcommand
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	by := fs.String("by", "day", "group by day, week (ISO), month or name")
+	since := fs.String("since", "", "only runs started on or after this date (YYYY-MM-DD, today, yesterday)")
+	until := fs.String("until", "", "only runs started on or before this date (YYYY-MM-DD, today, yesterday)")
+	name := fs.String("name", "", "only runs with this timer name")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: timer stats [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Reports focused time from the history log.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  timer stats -by week\n")
+		fmt.Fprintf(os.Stderr, "  timer stats -since 2026-10-01 -until today\n")
+		fmt.Fprintf(os.Stderr, "  timer stats -by name -json\n")
+	}
+	fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	switch *by {
+	case "day", "week", "month", "name":
+	default:
+		return fmt.Errorf("invalid grouping %q, expected day, week, month or name", *by)
+	}
+
+	var from, to time.Time
+	if *since != "" {
+		var err error
+		if from, err = parseDateArg(*since); err != nil {
+			return err
+		}
+	}
+	if *until != "" {
+		day, err := parseDateArg(*until)
+		if err != nil {
+			return err
+		}
+		// Inclusive: everything before the start of the following day
+		to = day.AddDate(0, 0, 1)
+	}
+
+	records, err := readHistory()
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string]*statsGroup)
+	total := &statsGroup{key: "total"}
+	for _, r := range records {
+		if !from.IsZero() && r.Start.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !r.Start.Before(to) {
+			continue
+		}
+		if *name != "" && r.Name != *name {
+			continue
+		}
+		key := statsKey(r, *by)
+		g, ok := groups[key]
+		if !ok {
+			g = &statsGroup{key: key}
+			groups[key] = g
+		}
+		g.add(r)
+		total.add(r)
+	}
+
+	sorted := make([]*statsGroup, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if *by == "name" {
+			// Most focused names first
+			if sorted[i].total != sorted[j].total {
+				return sorted[i].total > sorted[j].total
+			}
+		}
+		return sorted[i].key < sorted[j].key
+	})
+
+	if *asJSON {
+		data, err := json.MarshalIndent(struct {
+			By     string        `json:"by"`
+			Groups []*statsGroup `json:"groups"`
+			Total  *statsGroup   `json:"total"`
+		}{*by, sorted, total}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if total.sessions == 0 {
+		fmt.Println("no recorded runs")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "%s\tSESSIONS\tTOTAL\tCOMPLETED\tAVERAGE\tLONGEST\tPAUSED\t\n", map[string]string{
+		"day": "DAY", "week": "WEEK", "month": "MONTH", "name": "NAME",
+	}[*by])
+	for _, g := range append(sorted, total) {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%.0f%%\t%s\t%s\t%s\t\n",
+			g.key, g.sessions, formatHMS(g.total), g.completionRatio()*100,
+			formatHMS(g.average()), formatHMS(g.longest), formatHMS(g.paused))
+	}
+	return w.Flush()
+}
@@ -11,18 +11,30 @@ import (
 
 // Terminal escape codes
 const (
-	clearScreen = "\033[2J"
-	hideCursor  = "\033[?25l"
-	showCursor  = "\033[?25h"
-	altScreen   = "\033[?1049h"
-	mainScreen  = "\033[?1049l"
-	resetStyle  = "\033[0m"
-	blueColor   = "\033[34m"    // Blue text color
-	redColor    = "\033[31m"    // Red text color
-	mouseOn     = "\033[?1000h" // Enable basic mouse tracking
-	mouseOff    = "\033[?1000l" // Disable mouse tracking
+	clearScreen    = "\033[2J"
+	clearToEOL     = "\033[K" // Clear from cursor to end of line
+	hideCursor     = "\033[?25l"
+	showCursor     = "\033[?25h"
+	altScreen      = "\033[?1049h"
+	mainScreen     = "\033[?1049l"
+	resetStyle     = "\033[0m"
+	reverseVideo   = "\033[7m"                // Swap foreground and background (prompt cursor)
+	noReverseVideo = "\033[27m"               // End reverse video
+	blueColor      = "\033[34m"               // Blue text color
+	redColor       = "\033[31m"               // Red text color
+	purpleColor    = "\033[35m"               // Magenta text color (overtime)
+	mouseOn        = "\033[?1000h\033[?1006h" // Enable mouse tracking, with SGR reports where supported
+	mouseOff       = "\033[?1006l\033[?1000l" // Disable mouse tracking
 )
 
+// heatmapRamp is the 256-color palette from "no time" to "most time"
+var heatmapRamp = []int{237, 22, 28, 34, 40, 46}
+
+// color256 returns the escape code selecting a 256-color foreground
+func color256(n int) string {
+	return fmt.Sprintf("\033[38;5;%dm", n)
+}
+
 func moveCursor(row, col int) string {
 	return fmt.Sprintf("\033[%d;%dH", row, col)
 }
@@ -4,53 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
-	"os/signal"
-	"runtime"
 	"syscall"
 	"time"
 )
 
-// parseInput parses accumulated bytes into a key byte or ignores sequences
-func parseInput(seq []byte) (byte, bool) {
-	if len(seq) == 0 {
-		return 0, false
-	}
-	if len(seq) == 1 {
-		if seq[0] == 0x1b {
-			return 0, false // Wait for more or timeout
-		}
-		// Single key
-		return seq[0], true
-	}
-	// Check for complete escape sequences
-	if seq[0] == 0x1b {
-		// Mouse sequences: \033[M or \033[<...
-		if len(seq) >= 3 && (seq[1] == '[' || seq[1] == 'M') {
-			// Wait for end: for [ it's variable, for M it's 6 bytes
-			if seq[1] == 'M' && len(seq) >= 6 {
-				return 0, true // Ignore mouse
-			}
-			if seq[1] == '[' {
-				// Extended mouse ends with 'm' or 'M'
-				if seq[len(seq)-1] == 'm' || seq[len(seq)-1] == 'M' {
-					return 0, true // Ignore mouse
-				}
-				// If not ended, continue accumulating
-				return 0, false
-			}
-		}
-		// Other escape sequences (e.g., arrow keys), ignore for now
-		if len(seq) >= 3 && seq[len(seq)-1] >= 0x40 && seq[len(seq)-1] <= 0x7E {
-			return 0, true // Ignore other escapes
-		}
-		// Incomplete, continue
-		return 0, false
-	}
-	// Should not reach here, but if multiple bytes not starting with ESC, treat as single (though unlikely)
-	return seq[0], true
-}
-
 // getTickerInterval returns the appropriate ticker interval based on duration
 func getTickerInterval(duration time.Duration) time.Duration {
 	if duration == 0 {
@@ -66,6 +23,92 @@ func getTickerInterval(duration time.Duration) time.Duration {
 	return tickIntervalSlow
 }
 
+// timerOptions configures a single run of the timer
+type timerOptions struct {
+	duration       time.Duration // countdown length, 0 means counter mode
+	fullscreen     bool
+	paused         bool
+	name           string
+	initialElapsed time.Duration // elapsed time restored from a previous session
+	since          time.Time     // start of a since timer, zero otherwise
+	until          time.Time     // target of an until timer, zero otherwise
+	overtime       bool          // keep counting past zero until quit
+
+	// Multi-phase plan (nil unless running a pomodoro cycle or repeating)
+	plan   phasePlan
+	phases []PhaseRecord // completed phases restored from a previous session
+
+	laps []LapRecord // laps restored from a previous counter session
+
+	alerts  []alertMark // intermediate alerts of each countdown
+	alerted []string    // alerts already fired in a restored session
+
+	adjustments []AdjustmentRecord // adjustments restored from a previous session
+	restarts    []RestartRecord    // resets and restarts restored from a previous session
+
+	httpAddr string // address of the optional HTTP API, empty to disable
+}
+
+// timerFrame builds the display frame for the current timer state
+func timerFrame(st *timerState, now time.Time) frame {
+	var displayTime time.Duration
+	if st.isCounter() {
+		// Counter mode - count up
+		displayTime = st.elapsed(now)
+	} else {
+		// Timer mode - count down
+		displayTime = st.remaining(now)
+	}
+
+	f := frame{timeStr: formatHMS(displayTime)}
+	overtime := st.overtime && st.done(now)
+	if overtime {
+		f.timeStr = "-" + formatHMS(st.overrun(now))
+	}
+
+	// Determine color based on state and time remaining
+	if st.paused {
+		f.color = blueColor
+	} else if overtime {
+		f.color = purpleColor
+	} else if !st.isCounter() && displayTime < warningThreshold {
+		// Only show red warning in timer mode
+		f.color = redColor
+	}
+
+	f.buttons = frameButtons(st)
+
+	if st.plan != nil {
+		f.header = st.plan.header()
+	} else if !st.since.IsZero() {
+		f.header = "since " + formatWallClock(st.since, now)
+	} else if !st.until.IsZero() {
+		f.header = "until " + formatWallClock(st.until, now)
+	}
+
+	// Show the most recent laps, newest first
+	for i := len(st.laps) - 1; i >= 0 && len(st.laps)-i <= lapsShown; i-- {
+		l := st.laps[i]
+		f.footer = append(f.footer, fmt.Sprintf("L%d %s  %s",
+			l.Number,
+			formatLapTime(parseFormattedDuration(l.Lap)),
+			formatLapTime(parseFormattedDuration(l.Split))))
+	}
+	return f
+}
+
+// timerTitle returns the title of notifications: the timer name, or the kind
+// of timer when unnamed
+func timerTitle(st *timerState) string {
+	if st.name != "" {
+		return st.name
+	}
+	if st.mode() == "pomodoro" {
+		return "Pomodoro"
+	}
+	return "Timer"
+}
+
 func writeSession(session Session) {
 	data, err := json.MarshalIndent(session, "", "  ")
 	if err != nil {
@@ -75,494 +118,408 @@ func writeSession(session Session) {
 	_ = os.WriteFile("sessions.json", data, 0644)
 }
 
-func runTimer(duration time.Duration, useFullscreen bool, initialPaused bool, name string, initialElapsed time.Duration, summaryCh chan<- TimerSummary) error {
-	// Determine if counter mode (duration == 0)
-	isCounter := duration == 0
-
-	// Setup signal handling
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGWINCH)
-
-	// Enter alt screen if fullscreen
-	if useFullscreen {
-		fmt.Print(altScreen)
-		defer fmt.Print(mainScreen)
+func runTimer(opts timerOptions, summaryCh chan<- TimerSummary) error {
+	useFullscreen := opts.fullscreen
+
+	// State snapshots are published to the hub; HTTP API commands arrive on
+	// cmdCh and are executed by the loop below, which owns the state
+	hub := newStateHub()
+	cmdCh := make(chan timerCommand)
+	metrics := newTimerMetrics()
+	if opts.httpAddr != "" {
+		server, err := startHTTP(opts.httpAddr, hub, cmdCh, metrics)
+		if err != nil {
+			return err
+		}
+		defer server.Close()
+		defer hub.close()
 	}
 
-	// Hide cursor
-	fmt.Print(hideCursor)
-	defer fmt.Print(showCursor)
+	// Lifecycle hooks run in the background; wait for them before returning,
+	// but only once the terminal is restored (deferred calls run in reverse)
+	var hooks hookRunner
+	defer hooks.wait()
 
-	// Configure terminal for raw mode
-	oldState, err := setupTerminal()
+	t, err := openTUI(useFullscreen)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err := restoreTerminal(oldState); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to restore terminal: %v\n", err)
-		}
-	}()
+	defer t.close()
+	sigCh, keysCh := t.signals, t.keys
 
-	// Enable mouse tracking if fullscreen
-	if useFullscreen {
-		fmt.Print(mouseOn)
-		defer fmt.Print(mouseOff)
-	}
+	st := newTimerState(opts, time.Now())
 
-	// Channel for quit signal
-	quitCh := make(chan struct{})
-	defer close(quitCh)
-
-	// Channel for keyboard input
-	keysCh := make(chan byte, keyBufferSize)
-	defer close(keysCh)
-
-	// Start keyboard reader goroutine (blocking read, low CPU)
-	fd := int(syscall.Stdin)
-	go func() {
-		var seq []byte
-		var timer *time.Timer
-		var timerCh <-chan time.Time
-		for {
-			buf := make([]byte, 1)
-			readCh := make(chan []byte, 1)
-			go func() {
-				n, err := syscall.Read(fd, buf)
-				if err != nil {
-					readCh <- nil
-					return
-				}
-				if n > 0 {
-					readCh <- buf[:n]
-				} else {
-					readCh <- []byte{}
-				}
-			}()
-			select {
-			case data := <-readCh:
-				if data == nil {
-					// error
-					if timer != nil {
-						timer.Stop()
-					}
-					return
-				}
-				seq = append(seq, data[0])
-				if timer != nil {
-					timer.Stop()
-					timer = nil
-					timerCh = nil
-				}
-				if key, ok := parseInput(seq); ok {
-					if key != 0 {
-						select {
-						case keysCh <- key:
-						case <-quitCh:
-							return
-						default:
-							// Drop key if channel is full
-						}
-					}
-					seq = nil
-				} else if len(seq) == 1 && seq[0] == 0x1b {
-					// Start timer for ESC
-					timer = time.NewTimer(50 * time.Millisecond)
-					timerCh = timer.C
-				}
-			case <-timerCh:
-				// Timeout, treat as ESC
-				select {
-				case keysCh <- 0x1b:
-				case <-quitCh:
-					return
-				default:
-				}
-				seq = nil
-				timer = nil
-				timerCh = nil
-			case <-quitCh:
-				if timer != nil {
-					timer.Stop()
-				}
-				return
-			}
-		}
-	}()
+	// Notification failures are reported in the summary
+	var notes notifications
+
+	// Warn only when the threshold is crossed while running, not when the
+	// countdown starts below it
+	warned := st.isCounter() || st.remaining(time.Now()) < warningThreshold
+
+	// In overtime mode the countdown keeps running past zero; a restored
+	// session may already be past it
+	inOvertime := st.done(time.Now())
 
-	start := time.Now()
-	if initialElapsed > 0 {
-		start = start.Add(-initialElapsed)
-	}
 	// Use adaptive ticker interval based on duration
-	tickInterval := getTickerInterval(duration)
-	var ticker *time.Ticker = time.NewTicker(tickInterval)
-	defer func() {
-		if ticker != nil {
-			ticker.Stop()
-		}
-	}()
-
-	// Pause state
-	var paused = initialPaused
-	var pauseStart time.Time
-	var totalPausedDuration time.Duration
-	if paused {
-		pauseStart = time.Now()
-	}
+	tickInterval := getTickerInterval(st.duration)
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
 
 	// Cache for rendered output
 	var lastRenderedSec int64 = -1
 	var cachedOutput string
 
-	// Initial render - show the starting time immediately
-	var initialDisplayTime time.Duration
-	if isCounter {
-		// Counter mode - start at 00:00
-		initialDisplayTime = 0
-	} else {
-		// Timer mode - show full duration
-		initialDisplayTime = duration
+	// The last rendered frame and where it was drawn, for mouse clicks
+	var lastFrame frame
+	var layout frameLayout
+
+	// The ':' command prompt
+	var prompt palette
+
+	// Whether the help overlay is shown
+	var showHelp bool
+
+	// render refreshes the cached output when the displayed second changes
+	// (or a re-render was forced) and writes the session to file
+	render := func(now time.Time) {
+		f := timerFrame(st, now)
+		currentSec := int64(st.elapsed(now).Round(time.Second).Seconds())
+		if showHelp {
+			// The help shows the paused time, which moves while paused
+			currentSec += int64(st.pausedTotal(now).Round(time.Second).Seconds())
+		}
+		if currentSec == lastRenderedSec && lastRenderedSec != -1 {
+			return
+		}
+		lastRenderedSec = currentSec
+		session := st.session(now, false)
+		go writeSession(session) // Write asynchronously to avoid blocking UI
+		hub.publish("tick", session)
+		f.prompt = prompt.line()
+		if showHelp {
+			f.overlay = helpOverlay(st, now, useFullscreen, nil)
+		}
+		cachedOutput, layout = renderFrame(f, useFullscreen)
+		lastFrame = f
 	}
 
-	// Render initial state
-	timeStr := formatHMS(initialDisplayTime)
+	// redraw renders and prints immediately, e.g. while typing a command
+	redraw := func(now time.Time) {
+		lastRenderedSec = -1
+		render(now)
+		fmt.Print(cachedOutput)
+	}
 
-	// Determine color based on state and time remaining
-	var color string
-	if paused {
-		color = blueColor
-	} else if !isCounter && initialDisplayTime < warningThreshold {
-		// Only show red warning in timer mode
-		color = redColor
-	} else {
-		color = ""
+	// stateChanged forces a re-render and publishes the new state
+	stateChanged := func(now time.Time) {
+		lastRenderedSec = -1
+		hub.publish("state", st.session(now, false))
 	}
 
-	if useFullscreen {
-		width, height := getTerminalSize()
-		bigText := renderBigTime(timeStr, width, height)
-		centeredText := centerText(bigText, width, height)
-		if color != "" {
-			cachedOutput = color + centeredText + resetStyle
-		} else {
-			cachedOutput = centeredText
+	// setPaused pauses or resumes the timer
+	setPaused := func(paused bool, now time.Time) {
+		if st.paused == paused {
+			return
 		}
-		fmt.Print(clearScreen + moveCursor(1, 1) + fixNewlines(cachedOutput))
-	} else {
-		if color != "" {
-			cachedOutput = fmt.Sprintf("\r%s%s%s   ", color, timeStr, resetStyle)
+		st.togglePause(now)
+		if st.paused {
+			// Switch to slow ticker to reduce CPU usage
+			ticker.Reset(tickIntervalSlow)
+			hooks.fire("pause", st, now, false)
 		} else {
-			cachedOutput = fmt.Sprintf("\r%s   ", timeStr)
+			// Restart ticker with normal interval
+			ticker.Reset(tickInterval)
+			hooks.fire("resume", st, now, false)
 		}
-		fmt.Print(cachedOutput)
+		stateChanged(now)
 	}
 
-	// Write initial session state
-	var initialElapsedForDisplay time.Duration
-	if isCounter {
-		initialElapsedForDisplay = initialDisplayTime
-	} else {
-		initialElapsedForDisplay = initialElapsed
+	// adjust adds (or removes) time, from the keyboard or the HTTP API
+	adjust := func(d time.Duration, now time.Time) {
+		st.addTime(d, now)
+		tickInterval = getTickerInterval(st.duration)
+		if !st.paused {
+			ticker.Reset(tickInterval)
+		}
+		warned = st.isCounter() || st.remaining(now) < warningThreshold
+		inOvertime = st.done(now)
+		stateChanged(now)
 	}
-	initialSession := Session{
-		Start:    start.Format("2006-01-02:15-04-05"),
-		Current:  start.Format("2006-01-02:15-04-05"),
-		Elapsed:  formatDuration(initialElapsedForDisplay),
-		Paused:   paused,
-		Mode:     "timer",
-		Name:     name,
-		Finished: false,
-		Inline:   !useFullscreen,
+
+	// exit writes the final session state, fires the exit hook (finish,
+	// quit or interrupt) and sends the summary
+	exit := func(event string) {
+		now := time.Now()
+		// Quitting during overtime still completes the countdown
+		finished := event == "finish" || (st.overtime && st.done(now))
+		session := st.session(now, finished)
+		writeSession(session) // Synchronous write for final state
+		hub.publish("state", session)
+		hooks.fire(event, st, now, finished)
+		summary := st.summary(now, finished)
+		summary.NotifyFailures = notes.wait()
+		summaryCh <- summary
 	}
-	if isCounter {
-		initialSession.Mode = "counter"
-	} else {
-		initialSession.Remaining = formatDuration(initialDisplayTime)
+
+	title := timerTitle(st)
+
+	// endPhase ends the current countdown when it runs out (or is skipped):
+	// plans move on to their next phase, anything else finishes the run.
+	// It reports whether the run is over.
+	endPhase := func(now time.Time, skipped bool) bool {
+		if st.plan == nil || st.plan.last() {
+			message := "Timer finished!"
+			if st.plan != nil {
+				message = st.plan.finishedMessage()
+			}
+			fmt.Print("\r\nfinished!\r\n")
+			notes.send(title, message)
+			exit("finish")
+			return true
+		}
+		if !skipped {
+			notes.sendAsync(title, st.plan.finishedMessage())
+			hooks.fire("finish", st, now, true)
+		}
+		metrics.phaseEnded(st.name, skipped, st.elapsed(now))
+		st.nextPhase(now, skipped)
+		tickInterval = getTickerInterval(st.duration)
+		ticker.Reset(tickInterval)
+		stateChanged(now)
+		warned = st.remaining(now) < warningThreshold
+		return false
+	}
+
+	// restart starts the countdown (or stopwatch) over at its full length:
+	// "reset" leaves it paused, "restart" running
+	restart := func(action string, now time.Time) error {
+		if !st.since.IsZero() {
+			return fmt.Errorf("a since timer cannot %s", action)
+		}
+		if !st.until.IsZero() {
+			return fmt.Errorf("an until timer cannot %s", action)
+		}
+		st.restart(action, now)
+		setPaused(action == "reset", now)
+		warned = st.isCounter() || st.remaining(now) < warningThreshold
+		inOvertime = false
+		stateChanged(now)
+		return nil
 	}
-	go writeSession(initialSession)
 
-	lastRenderedSec = int64(initialDisplayTime.Seconds())
+	// finishNow ends the run as finished, early if the countdown (or plan)
+	// had not run out
+	finishNow := func(now time.Time) {
+		st.early = (st.plan != nil && !st.plan.last()) || (!st.isCounter() && !st.done(now))
+		fmt.Print("\r\nfinished!\r\n")
+		exit("finish")
+	}
+
+	// runCommand runs a line entered at the ':' prompt and returns the
+	// message shown in its place, or true when the run is over
+	runCommand := func(line string) (string, bool) {
+		now := time.Now()
+		cmd, err := parseCommand(line)
+		if err != nil {
+			return err.Error(), false
+		}
+		switch cmd.name {
+		case "set":
+			if st.isCounter() {
+				return "set needs a countdown, use add to move a stopwatch", false
+			}
+			adjust(cmd.duration-st.duration, now)
+			return "length set to " + formatHMS(st.duration), false
+
+		case "add":
+			adjust(cmd.duration, now)
+			return "added " + formatAdjustment(cmd.duration), false
+
+		case "name":
+			st.name = cmd.text
+			title = timerTitle(st)
+			stateChanged(now)
+			return fmt.Sprintf("name set to %q", st.name), false
+
+		case "reset", "restart":
+			if err := restart(cmd.name, now); err != nil {
+				return err.Error(), false
+			}
+			return cmd.name + " to " + formatHMS(max(st.duration, 0)), false
+
+		case "finish":
+			finishNow(now)
+			return "", true
+
+		case "overtime":
+			if st.isCounter() || st.plan != nil {
+				return "overtime only applies to single countdowns", false
+			}
+			// Turning it off past zero finishes on the next tick
+			st.overtime = cmd.on
+			inOvertime = cmd.on && st.done(now)
+			stateChanged(now)
+			if cmd.on {
+				return "overtime on", false
+			}
+			return "overtime off", false
+		}
+		return "", false
+	}
+
+	// runAction runs a key action (from the keyboard or the mouse) and
+	// reports whether the run is over
+	runAction := func(action string) bool {
+		now := time.Now()
+		switch action {
+		case "pause":
+			setPaused(!st.paused, now)
+
+		case "lap": // Record a lap (counter mode only)
+			if st.isCounter() {
+				st.lap(now)
+				stateChanged(now)
+			}
+
+		case "add-time", "remove-time", "add-time-small", "remove-time-small":
+			adjust(actionAdjustment(action), now)
+
+		case "skip": // Skip to the next phase of a plan
+			return st.plan != nil && endPhase(now, true)
+
+		case "reset", "restart":
+			if err := restart(action, now); err != nil {
+				prompt.message = err.Error()
+			}
+			redraw(now)
+
+		case "finish": // Finish now, even before the countdown runs out
+			finishNow(now)
+			return true
+
+		case "command": // Open the command prompt
+			prompt.start()
+			redraw(now)
+
+		case "help": // Show or hide the help overlay
+			showHelp = !showHelp
+			redraw(now)
+
+		case "quit":
+			fmt.Print("\r\nquitting...\r\n")
+			exit("quit")
+			return true
+
+		case "interrupt":
+			exit("interrupt")
+			return true
+		}
+		return false
+	}
+
+	hooks.fire("start", st, time.Now(), false)
+
+	// Render initial state - show the starting time immediately
+	render(time.Now())
+	fmt.Print(cachedOutput)
 
 	for {
 		select {
 		case sig := <-sigCh:
 			if sig == syscall.SIGWINCH {
-				// Terminal resized - force re-render
+				// Terminal resized - re-render now so the layout used for
+				// mouse clicks matches the screen
 				lastRenderedSec = -1
+				render(time.Now())
+				fmt.Print(cachedOutput)
 				continue
 			}
 			// Handle interrupt/terminate signals
-			end := time.Now()
-			effectiveDuration := time.Since(start) - totalPausedDuration
-			if paused {
-				effectiveDuration -= time.Since(pauseStart)
-			}
-			mode := "timer"
-			if isCounter {
-				mode = "counter"
-			}
-			// Write final session state
-			signalSession := Session{
-				Start:    start.Format("2006-01-02:15-04-05"),
-				Current:  end.Format("2006-01-02:15-04-05"),
-				Elapsed:  formatDuration(effectiveDuration),
-				Paused:   paused,
-				Mode:     mode,
-				Name:     name,
-				Finished: false,
-				Inline:   !useFullscreen,
-			}
-			if !isCounter {
-				remaining := duration - effectiveDuration
-				if remaining < 0 {
-					remaining = 0
-				}
-				signalSession.Remaining = formatDuration(remaining)
-			}
-			writeSession(signalSession) // Synchronous write for final state
-			summaryCh <- TimerSummary{
-				Start:    start,
-				End:      end,
-				Duration: effectiveDuration,
-				Mode:     mode,
-				Finished: false,
-				Name:     name,
-			}
+			exit("interrupt")
 			return nil
 
 		case key := <-keysCh:
-			// Handle keyboard input
-			switch key {
-			case 0x20: // Space key - pause/unpause
-				if paused {
-					// Unpause
-					totalPausedDuration += time.Since(pauseStart)
-					paused = false
-					// Restart ticker with normal interval
-					if ticker != nil {
-						ticker.Stop()
+			if prompt.open {
+				// The prompt takes all keys until enter or esc
+				if line, ok := prompt.key(key); ok {
+					message, done := runCommand(line)
+					if done {
+						return nil
 					}
-					ticker = time.NewTicker(tickInterval)
-				} else {
-					// Pause
-					paused = true
-					pauseStart = time.Now()
-					// Switch to slow ticker to reduce CPU usage
-					if ticker != nil {
-						ticker.Stop()
-					}
-					ticker = time.NewTicker(tickIntervalSlow)
+					prompt.message = message
 				}
-				// Force re-render
+				redraw(time.Now())
+				continue
+			}
+			if prompt.message != "" {
+				// Any key dismisses the last command's message
+				prompt.message = ""
 				lastRenderedSec = -1
+			}
+			// Handle keyboard input through the keymap
+			if runAction(keymap.action[key]) {
+				return nil
+			}
 
-			case 'q', 'Q', 0x1b: // q, Q, or ESC - quit
-				fmt.Print("\r\nquitting...\r\n")
-				end := time.Now()
-				effectiveDuration := time.Since(start) - totalPausedDuration
-				if paused {
-					effectiveDuration -= time.Since(pauseStart)
-				}
-				mode := "timer"
-				if isCounter {
-					mode = "counter"
-				}
-				// Write final session state
-				quitSession := Session{
-					Start:    start.Format("2006-01-02:15-04-05"),
-					Current:  end.Format("2006-01-02:15-04-05"),
-					Elapsed:  formatDuration(effectiveDuration),
-					Paused:   paused,
-					Mode:     mode,
-					Name:     name,
-					Finished: false,
-					Inline:   !useFullscreen,
-				}
-				if !isCounter {
-					remaining := duration - effectiveDuration
-					if remaining < 0 {
-						remaining = 0
-					}
-					quitSession.Remaining = formatDuration(remaining)
-				}
-				writeSession(quitSession) // Synchronous write for final state
-				summaryCh <- TimerSummary{
-					Start:    start,
-					End:      end,
-					Duration: effectiveDuration,
-					Mode:     mode,
-					Finished: false,
-				}
+		case m := <-t.mouse:
+			// Clicks and the wheel run the same actions as keys
+			if runAction(mouseAction(m, lastFrame, layout)) {
 				return nil
+			}
 
-			case 0x03: // Ctrl+C
-				end := time.Now()
-				effectiveDuration := time.Since(start) - totalPausedDuration
-				if paused {
-					effectiveDuration -= time.Since(pauseStart)
-				}
-				mode := "timer"
-				if isCounter {
-					mode = "counter"
-				}
-				// Write final session state
-				ctrlcSession := Session{
-					Start:    start.Format("2006-01-02:15-04-05"),
-					Current:  end.Format("2006-01-02:15-04-05"),
-					Elapsed:  formatDuration(effectiveDuration),
-					Paused:   paused,
-					Mode:     mode,
-					Name:     name,
-					Finished: false,
-					Inline:   !useFullscreen,
-				}
-				if !isCounter {
-					remaining := duration - effectiveDuration
-					if remaining < 0 {
-						remaining = 0
-					}
-					ctrlcSession.Remaining = formatDuration(remaining)
-				}
-				writeSession(ctrlcSession) // Synchronous write for final state
-				summaryCh <- TimerSummary{
-					Start:    start,
-					End:      end,
-					Duration: effectiveDuration,
-					Mode:     mode,
-					Finished: false,
+		case cmd := <-cmdCh:
+			// Handle HTTP API commands
+			now := time.Now()
+			var err error
+			switch cmd.action {
+			case "pause", "resume":
+				if st.paused == (cmd.action == "pause") {
+					err = fmt.Errorf("timer is already %sd", cmd.action)
+				} else {
+					setPaused(cmd.action == "pause", now)
 				}
+			case "add-time":
+				adjust(cmd.delta, now)
+			case "stop":
+				cmd.reply <- timerCommandResult{session: st.session(now, false)}
+				fmt.Print("\r\nstopped\r\n")
+				exit("quit")
 				return nil
 			}
+			cmd.reply <- timerCommandResult{session: st.session(now, false), err: err}
 
 		case <-ticker.C:
-			// Calculate effective elapsed time (excluding paused duration)
-			elapsed := time.Since(start) - totalPausedDuration
-			if paused {
-				elapsed -= time.Since(pauseStart)
-			}
-
-			var displayTime time.Duration
-			var currentSec int64
-
-			if isCounter {
-				// Counter mode - count up
-				displayTime = elapsed
-				currentSec = int64(elapsed.Seconds())
-				// Never exit automatically in counter mode
-			} else {
-				// Timer mode - count down
-				if elapsed >= duration {
-					// Timer finished
-					fmt.Print("\r\nfinished!\r\n")
-					end := time.Now()
-					effectiveDuration := time.Since(start) - totalPausedDuration
-					if paused {
-						effectiveDuration -= time.Since(pauseStart)
-					}
-					// Write final session state
-					finalSession := Session{
-						Start:     start.Format("2006-01-02:15-04-05"),
-						Current:   end.Format("2006-01-02:15-04-05"),
-						Elapsed:   formatDuration(effectiveDuration),
-						Remaining: formatDuration(0),
-						Paused:    false,
-						Mode:      "timer",
-						Name:      name,
-						Finished:  true,
-						Inline:    !useFullscreen,
-					}
-					writeSession(finalSession) // Synchronous write for final state
-					summaryCh <- TimerSummary{
-						Start:    start,
-						End:      end,
-						Duration: effectiveDuration,
-						Mode:     "timer",
-						Finished: true,
-						Name:     name,
-					}
-					if runtime.GOOS == "linux" {
-						title := "Timer"
-						if name != "" {
-							title = name
-						}
-						exec.Command("notify-send", title, "Timer finished!").Run()
-					}
-					return nil
+			now := time.Now()
+			if st.done(now) && st.overtime {
+				if !inOvertime {
+					// Zero reached - keep counting until quit
+					inOvertime = true
+					notes.sendAsync(title, "Timer finished! Counting overtime")
+					hooks.fire("finish", st, now, true)
+					stateChanged(now)
 				}
-				displayTime = duration - elapsed
-				currentSec = int64(displayTime.Seconds())
+			} else if st.done(now) && endPhase(now, false) {
+				return nil
 			}
 
-			// Re-render when second changes OR when paused state changes
-			if currentSec != lastRenderedSec || lastRenderedSec == -1 {
-				lastRenderedSec = currentSec
-
-				// Write current session to file
-				currentTime := time.Now()
-				session := Session{
-					Start:    start.Format("2006-01-02:15-04-05"),
-					Current:  currentTime.Format("2006-01-02:15-04-05"),
-					Elapsed:  formatDuration(elapsed),
-					Paused:   paused,
-					Mode:     "timer",
-					Name:     name,
-					Finished: false,
-					Inline:   !useFullscreen,
-				}
-				if isCounter {
-					session.Mode = "counter"
-				} else {
-					session.Remaining = formatDuration(displayTime)
-				}
-				go writeSession(session) // Write asynchronously to avoid blocking UI
-
-				// Format time
-				timeStr := formatHMS(displayTime)
-
-				// Determine color based on state and time remaining
-				var color string
-				if paused {
-					color = blueColor
-				} else if !isCounter && displayTime < warningThreshold {
-					// Only show red warning in timer mode
-					color = redColor
-				} else {
-					color = ""
-				}
-
-				if useFullscreen {
-					// Get terminal size
-					width, height := getTerminalSize()
-
-					// Render big text
-					bigText := renderBigTime(timeStr, width, height)
-
-					// Center the output first
-					centeredText := centerText(bigText, width, height)
-
-					// Apply color (paused = blue, <5min = red, else = default)
-					if color != "" {
-						cachedOutput = color + centeredText + resetStyle
-					} else {
-						cachedOutput = centeredText
-					}
-				} else {
-					// Simple inline display
-					if color != "" {
-						cachedOutput = fmt.Sprintf("\r%s%s%s   ", color, timeStr, resetStyle)
-					} else {
-						cachedOutput = fmt.Sprintf("\r%s   ", timeStr)
-					}
+			for range st.dueAlerts(now) {
+				notes.sendAsync(title, fmt.Sprintf("%s remaining", formatHMS(st.remaining(now))))
+				// Ring once: the chain may ring the bell itself
+				if alertBell && !chainHasBell() {
+					fmt.Print("\a")
 				}
 			}
 
-			// Output the cached rendering (fix newlines for raw mode)
-			if useFullscreen {
-				fmt.Print(clearScreen + moveCursor(1, 1) + fixNewlines(cachedOutput))
-			} else {
-				fmt.Print(cachedOutput)
+			if !warned && !st.paused && st.remaining(now) < warningThreshold {
+				warned = true
+				hooks.fire("warning", st, now, false)
 			}
+
+			// Re-render when second changes OR when paused state changes
+			render(now)
+
+			// Output the cached rendering
+			fmt.Print(cachedOutput)
 		}
 	}
 }
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// tui owns the terminal while a timer is displayed: raw mode, alt screen,
+// signal handling and the keyboard reader
+type tui struct {
+	fullscreen bool
+	signals    chan os.Signal
+	keys       chan keyEvent
+	mouse      chan mouseEvent
+	quitCh     chan struct{}
+	oldState   *term.State
+}
+
+// openTUI prepares the terminal for the timer display and starts reading keys
+func openTUI(useFullscreen bool) (*tui, error) {
+	t := &tui{
+		fullscreen: useFullscreen,
+		signals:    make(chan os.Signal, 1),
+		keys:       make(chan keyEvent, keyBufferSize),
+		mouse:      make(chan mouseEvent, keyBufferSize),
+		quitCh:     make(chan struct{}),
+	}
+
+	// Setup signal handling
+	signal.Notify(t.signals, os.Interrupt, syscall.SIGTERM, syscall.SIGWINCH)
+
+	// Configure terminal for raw mode
+	oldState, err := setupTerminal()
+	if err != nil {
+		signal.Stop(t.signals)
+		return nil, err
+	}
+	t.oldState = oldState
+
+	// Enter alt screen and enable mouse tracking if fullscreen
+	if useFullscreen {
+		fmt.Print(altScreen)
+		fmt.Print(mouseOn)
+	}
+
+	// Hide cursor
+	fmt.Print(hideCursor)
+
+	go t.readKeys()
+	return t, nil
+}
+
+// close stops the keyboard reader and restores the terminal
+func (t *tui) close() {
+	close(t.quitCh)
+	signal.Stop(t.signals)
+	fmt.Print(showCursor)
+	if t.fullscreen {
+		fmt.Print(mouseOff)
+		fmt.Print(mainScreen)
+	}
+	if err := restoreTerminal(t.oldState); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to restore terminal: %v\n", err)
+	}
+}
+
+// readKeys reads stdin byte by byte and sends parsed keys (blocking read, low CPU)
+func (t *tui) readKeys() {
+	fd := int(syscall.Stdin)
+	keysCh, mouseCh, quitCh := t.keys, t.mouse, t.quitCh
+
+	// A single reader goroutine, so no byte is lost to an abandoned read
+	// when the ESC timeout fires. nil reports a read error.
+	readCh := make(chan []byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			n, err := syscall.Read(fd, buf)
+			var data []byte
+			if err == nil {
+				data = append([]byte{}, buf[:n]...)
+			}
+			select {
+			case readCh <- data:
+			case <-quitCh:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var seq []byte
+	var timer *time.Timer
+	var timerCh <-chan time.Time
+	for {
+		select {
+		case data := <-readCh:
+			if data == nil {
+				// error
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+			if len(data) == 0 {
+				continue
+			}
+			seq = append(seq, data[0])
+			if timer != nil {
+				timer.Stop()
+				timer = nil
+				timerCh = nil
+			}
+			if key, ok := parseKey(seq); ok {
+				if m, ok := parseMouse(seq); ok {
+					select {
+					case mouseCh <- m:
+					case <-quitCh:
+						return
+					default:
+						// Drop the event if the channel is full
+					}
+				} else if key != "" {
+					// Keys wait for room rather than being dropped, so
+					// commands typed or pasted at the prompt arrive whole
+					select {
+					case keysCh <- key:
+					case <-quitCh:
+						return
+					}
+				}
+				seq = nil
+			} else if len(seq) == 1 && seq[0] == 0x1b {
+				// Start timer for ESC
+				timer = time.NewTimer(50 * time.Millisecond)
+				timerCh = timer.C
+			}
+		case <-timerCh:
+			// Timeout, treat as ESC
+			select {
+			case keysCh <- "esc":
+			case <-quitCh:
+				return
+			default:
+			}
+			seq = nil
+			timer = nil
+			timerCh = nil
+		case <-quitCh:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
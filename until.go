@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseUntil parses the target of 'timer until' (see parseWallClock). A bare
+// time means its next occurrence, today or tomorrow.
+func parseUntil(args []string, now time.Time) (time.Time, error) {
+	target, bare, err := parseWallClock(args, now, "until")
+	if err != nil {
+		return time.Time{}, err
+	}
+	if bare && !target.After(now) {
+		target, _, err = parseWallClock(append([]string{"tomorrow"}, args...), now, "until")
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	if !target.After(now) {
+		return time.Time{}, fmt.Errorf("%s is in the past", target.Format("2006-01-02 15:04:05"))
+	}
+	return target, nil
+}
+
+// parseWallClock parses a local date and time given to a command such as
+// 'timer until':
+//
+//	17:30                  today, reported as bare
+//	today 17:30, tomorrow 09:00, yesterday 23:00
+//	2026-12-31T23:59, 2026-12-31 23:59, 2026-12-31 (midnight)
+//
+// Dates are built from calendar fields rather than by adding 24h, so days
+// across a DST change are counted correctly (see localTime).
+func parseWallClock(args []string, now time.Time, command string) (t time.Time, bare bool, err error) {
+	spec := strings.TrimSpace(strings.Join(args, " "))
+	if spec == "" {
+		return time.Time{}, false, fmt.Errorf("%s needs a time, e.g. 'timer %s 17:30'", command, command)
+	}
+
+	// The day is split off at the first space, or at the T of a date and
+	// time such as 2026-12-31T23:59
+	day, clock := "", spec
+	if i := strings.IndexByte(spec, ' '); i >= 0 {
+		day, clock = spec[:i], strings.TrimSpace(spec[i+1:])
+	} else if i := strings.IndexAny(spec, "Tt"); i >= 0 && strings.Contains(spec[:i], "-") {
+		day, clock = spec[:i], spec[i+1:]
+	} else if strings.Contains(spec, "-") {
+		day, clock = spec, "00:00"
+	}
+
+	year, month, date := now.In(time.Local).Date()
+	switch strings.ToLower(day) {
+	case "", "today":
+	case "tomorrow":
+		date++
+	case "yesterday":
+		date--
+	default:
+		d, err := time.ParseInLocation("2006-01-02", day, time.Local)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q, expected YYYY-MM-DD, today, tomorrow or yesterday", day)
+		}
+		year, month, date = d.Date()
+	}
+
+	var at time.Time
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if at, err = time.Parse(layout, clock); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid time %q, expected HH:MM or HH:MM:SS", clock)
+	}
+	return localTime(year, month, date, at), day == "", nil
+}
+
+// localTime returns the local instant of a date and clock time. A time that
+// does not exist because clocks jump forward moves past the jump (02:30 on
+// the spring-forward day becomes 03:30); a repeated time is its first
+// occurrence.
+func localTime(year int, month time.Month, day int, clock time.Time) time.Time {
+	t := time.Date(year, month, day, clock.Hour(), clock.Minute(), clock.Second(), 0, time.Local)
+	wanted := time.Date(year, month, day, clock.Hour(), clock.Minute(), clock.Second(), 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	if gap := wanted.Sub(got); gap > 0 {
+		t = t.Add(gap)
+	}
+	return t
+}
+
+// formatWallClock formats a time for a timer header or the help overlay: the time alone
+// when it falls today, otherwise with its date
+func formatWallClock(target, now time.Time) string {
+	ty, tm, td := target.Date()
+	ny, nm, nd := now.In(target.Location()).Date()
+	if ty == ny && tm == nm && td == nd {
+		return target.Format("15:04")
+	}
+	return target.Format("2006-01-02 15:04")
+}
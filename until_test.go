@@ -0,0 +1,111 @@
+package main
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // Zone data for the DST cases, wherever the tests run
+)
+
+// inZone runs f with time.Local set to the named zone
+func inZone(t *testing.T, name string, f func(loc *time.Location)) {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := time.Local
+	time.Local = loc
+	defer func() { time.Local = saved }()
+	f(loc)
+}
+
+func TestParseWallClock(t *testing.T) {
+	inZone(t, "America/New_York", func(loc *time.Location) {
+		now := time.Date(2026, 10, 17, 12, 0, 0, 0, loc)
+		tests := []struct {
+			spec string
+			want time.Time
+			bare bool
+		}{
+			{"17:30", time.Date(2026, 10, 17, 17, 30, 0, 0, loc), true},
+			{"09:05:30", time.Date(2026, 10, 17, 9, 5, 30, 0, loc), true},
+			{"today 17:30", time.Date(2026, 10, 17, 17, 30, 0, 0, loc), false},
+			{"Today 17:30", time.Date(2026, 10, 17, 17, 30, 0, 0, loc), false},
+			{"TOMORROW 09:00", time.Date(2026, 10, 18, 9, 0, 0, 0, loc), false},
+			{"yesterday 23:00", time.Date(2026, 10, 16, 23, 0, 0, 0, loc), false},
+			{"2026-12-31T23:59", time.Date(2026, 12, 31, 23, 59, 0, 0, loc), false},
+			{"2026-12-31t23:59", time.Date(2026, 12, 31, 23, 59, 0, 0, loc), false},
+			{"2026-12-31 23:59", time.Date(2026, 12, 31, 23, 59, 0, 0, loc), false},
+			{"2026-12-31", time.Date(2026, 12, 31, 0, 0, 0, 0, loc), false},
+
+			// Spring forward on 2026-03-08: 02:00-03:00 does not exist
+			{"2026-03-08 02:30", time.Date(2026, 3, 8, 3, 30, 0, 0, loc), false},
+			{"2026-03-08 03:00", time.Date(2026, 3, 8, 3, 0, 0, 0, loc), false},
+			{"2026-03-08 01:59", time.Date(2026, 3, 8, 1, 59, 0, 0, loc), false},
+
+			// Fall back on 2026-11-01: 01:00-02:00 happens twice, the
+			// first time in EDT (UTC-4)
+			{"2026-11-01 01:30", time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), false},
+			{"2026-11-01 02:30", time.Date(2026, 11, 1, 7, 30, 0, 0, time.UTC), false},
+		}
+		for _, tt := range tests {
+			got, bare, err := parseWallClock([]string{tt.spec}, now, "until")
+			if err != nil {
+				t.Errorf("parseWallClock(%q): %v", tt.spec, err)
+				continue
+			}
+			if !got.Equal(tt.want) || bare != tt.bare {
+				t.Errorf("parseWallClock(%q) = %v, %v, want %v, %v", tt.spec, got, bare, tt.want, tt.bare)
+			}
+		}
+	})
+}
+
+func TestParseWallClockErrors(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local)
+	for _, spec := range []string{"", "Today", "someday 17:30", "17:30:99", "25:00", "2026-13-01 10:00", "2026-12-31T"} {
+		if got, _, err := parseWallClock([]string{spec}, now, "until"); err == nil {
+			t.Errorf("parseWallClock(%q) = %v, want an error", spec, got)
+		}
+	}
+}
+
+func TestParseUntilAndSinceRollOverMidnight(t *testing.T) {
+	inZone(t, "America/New_York", func(loc *time.Location) {
+		tests := []struct {
+			name string
+			now  time.Time
+			args []string
+			want time.Time
+		}{
+			{"until tomorrow", time.Date(2026, 10, 17, 23, 50, 0, 0, loc), []string{"00:10"}, time.Date(2026, 10, 18, 0, 10, 0, 0, loc)},
+			{"until later today", time.Date(2026, 10, 17, 23, 50, 0, 0, loc), []string{"23:55"}, time.Date(2026, 10, 17, 23, 55, 0, 0, loc)},
+			{"until next year", time.Date(2026, 12, 31, 23, 50, 0, 0, loc), []string{"tomorrow", "00:10"}, time.Date(2027, 1, 1, 0, 10, 0, 0, loc)},
+			{"until across spring forward", time.Date(2026, 3, 7, 23, 0, 0, 0, loc), []string{"03:30"}, time.Date(2026, 3, 8, 3, 30, 0, 0, loc)},
+		}
+		for _, tt := range tests {
+			got, err := parseUntil(tt.args, tt.now)
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			} else if !got.Equal(tt.want) {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			}
+		}
+
+		// The spring-forward night is an hour short, the fall-back night
+		// an hour long
+		spring, _ := parseUntil([]string{"08:00"}, time.Date(2026, 3, 7, 22, 0, 0, 0, loc))
+		if d := spring.Sub(time.Date(2026, 3, 7, 22, 0, 0, 0, loc)); d != 9*time.Hour {
+			t.Errorf("22:00 to 08:00 across spring forward = %v, want 9h", d)
+		}
+		fall, _ := parseUntil([]string{"08:00"}, time.Date(2026, 10, 31, 22, 0, 0, 0, loc))
+		if d := fall.Sub(time.Date(2026, 10, 31, 22, 0, 0, 0, loc)); d != 11*time.Hour {
+			t.Errorf("22:00 to 08:00 across fall back = %v, want 11h", d)
+		}
+
+		start, err := parseSince([]string{"23:50"}, time.Date(2026, 10, 17, 0, 10, 0, 0, loc))
+		if want := time.Date(2026, 10, 16, 23, 50, 0, 0, loc); err != nil || !start.Equal(want) {
+			t.Errorf("since 23:50 at 00:10 = %v, %v, want %v", start, err, want)
+		}
+	})
+}
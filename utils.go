@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strconv"
 	"time"
 )
 
@@ -12,9 +11,19 @@ type TimerSummary struct {
 	Start    time.Time
 	End      time.Time
 	Duration time.Duration
-	Mode     string // "timer" or "counter"
-	Finished bool   // true if completed, false if quit/interrupted
-	Name     string // optional name for the timer
+	Paused   time.Duration // total time spent paused
+	Mode     string        // "timer" or "counter"
+	Finished bool          // true if completed, false if quit/interrupted
+	Early    bool          // finished with the finish key before running out
+	Name     string        // optional name for the timer
+	Phases   []PhaseRecord // completed pomodoro phases
+	Laps     []LapRecord   // laps recorded in counter mode
+	Overtime time.Duration // time run past zero in overtime mode
+
+	Adjustments []AdjustmentRecord // time added or removed while running
+	Restarts    []RestartRecord    // resets and restarts, oldest first
+
+	NotifyFailures []string // notification backends that failed
 }
 
 type Session struct {
@@ -22,18 +31,99 @@ type Session struct {
 	Current   string `json:"current"`
 	Elapsed   string `json:"elapsed"`
 	Remaining string `json:"remaining,omitempty"` // Only for timer mode
+	Overtime  string `json:"overtime,omitempty"`  // Time past zero, only in overtime mode
 	Paused    bool   `json:"paused"`
 	Mode      string `json:"mode"` // "timer" or "counter"
 	Name      string `json:"name,omitempty"`
 	Finished  bool   `json:"finished"`
-	Inline    bool   `json:"inline"` // true if inline mode, false if fullscreen
+	Early     bool   `json:"early,omitempty"` // finished with the finish key before running out
+	Inline    bool   `json:"inline"`          // true if inline mode, false if fullscreen
+	Since     string `json:"since,omitempty"` // RFC 3339 start of a since timer
+	Until     string `json:"until,omitempty"` // RFC 3339 target of an until timer
+
+	// Multi-phase plan state (only for pomodoro, repeat and segments modes)
+	Pomodoro *PomodoroSession `json:"pomodoro,omitempty"`
+	Repeat   *RepeatSession   `json:"repeat,omitempty"`
+	Segments *SegmentsSession `json:"segments,omitempty"`
+	Phases   []PhaseRecord    `json:"phases,omitempty"`
+
+	// Laps recorded in counter mode
+	Laps []LapRecord `json:"laps,omitempty"`
+
+	// Intermediate alerts already fired in the current countdown
+	Alerts []string `json:"alerts,omitempty"`
+
+	// Time added or removed while running
+	Adjustments []AdjustmentRecord `json:"adjustments,omitempty"`
+
+	// Resets and restarts of the current countdown or stopwatch
+	Restarts []RestartRecord `json:"restarts,omitempty"`
 }
 
-func addSuffixIfArgIsNumber(s *string, suffix string) {
-	_, err := strconv.ParseFloat(*s, 64)
-	if err == nil {
-		*s = *s + suffix
-	}
+// PomodoroSession stores the pomodoro plan and the current phase
+type PomodoroSession struct {
+	Work           string `json:"work"`
+	ShortBreak     string `json:"shortBreak"`
+	LongBreak      string `json:"longBreak"`
+	LongBreakEvery int    `json:"longBreakEvery"`
+	PhaseIndex     int    `json:"phaseIndex"`
+	Phase          string `json:"phase"`
+	Cycle          int    `json:"cycle"`
+}
+
+// RepeatSession stores a repeated countdown and the current iteration
+type RepeatSession struct {
+	Duration  string `json:"duration"`
+	Count     int    `json:"count"` // 0 repeats forever
+	Iteration int    `json:"iteration"`
+}
+
+// SegmentsSession stores chained segments (or an expanded sequence file)
+// and the current one
+type SegmentsSession struct {
+	Segments []SegmentSpec `json:"segments"`
+	Index    int           `json:"index"`
+	Source   string        `json:"source,omitempty"` // sequence file name
+}
+
+// SegmentSpec is one labelled countdown of a chained run
+type SegmentSpec struct {
+	Duration string `json:"duration"`
+	Label    string `json:"label"`
+	Context  string `json:"context,omitempty"` // e.g. "round 3/8"
+}
+
+// LapRecord is a lap recorded in counter mode
+type LapRecord struct {
+	Number int    `json:"number"`
+	Lap    string `json:"lap"`   // time since the previous lap
+	Split  string `json:"split"` // cumulative time at the lap
+}
+
+// AdjustmentRecord is time added to (or removed from) a running timer
+type AdjustmentRecord struct {
+	Time    string `json:"time"`
+	Elapsed string `json:"elapsed"` // elapsed time when adjusted
+	Delta   string `json:"delta"`   // negative when time was removed
+}
+
+// RestartRecord is a reset (left paused) or restart of the current
+// countdown or stopwatch
+type RestartRecord struct {
+	Time    string `json:"time"`
+	Action  string `json:"action"`  // "reset" or "restart"
+	Elapsed string `json:"elapsed"` // elapsed time discarded
+}
+
+// PhaseRecord is a completed phase of a multi-phase run
+type PhaseRecord struct {
+	Phase   string `json:"phase"`
+	Cycle   int    `json:"cycle"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	Elapsed string `json:"elapsed"`
+	Planned string `json:"planned,omitempty"` // planned phase length
+	Skipped bool   `json:"skipped,omitempty"` // ended early with the skip key
 }
 
 func formatDuration(d time.Duration) string {
@@ -43,6 +133,21 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%.1fs", d.Seconds())
 }
 
+// formatLapTime formats a duration as MM:SS.t (or H:MM:SS.t) for lap display
+func formatLapTime(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	tenths := int(d.Round(100*time.Millisecond) / (100 * time.Millisecond))
+	h := tenths / 36000
+	m := (tenths % 36000) / 600
+	s := (tenths % 600) / 10
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d.%d", h, m, s, tenths%10)
+	}
+	return fmt.Sprintf("%02d:%02d.%d", m, s, tenths%10)
+}
+
 func parseFormattedDuration(s string) time.Duration {
 	if s == "0s" {
 		return 0
@@ -55,6 +160,16 @@ func parseFormattedDuration(s string) time.Duration {
 	return time.Duration(sec * float64(time.Second))
 }
 
+// netAdjustment returns the time added by adjustments, negative when more
+// was removed
+func netAdjustment(adjustments []AdjustmentRecord) time.Duration {
+	var total time.Duration
+	for _, a := range adjustments {
+		total += parseFormattedDuration(a.Delta)
+	}
+	return total
+}
+
 func loadSession() (Session, error) {
 	data, err := os.ReadFile("sessions.json")
 	if err != nil {